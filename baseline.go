@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// BaselineVersion is the version of the baseline file format written by this package.
+const BaselineVersion = 1
+
+// Baseline records the fingerprints of known diagnostics allowing stricter validation to be
+// adopted incrementally: diagnostics within the baseline are excluded so only new diagnostics
+// are reported.
+//
+// Fingerprints are based on the code of the diagnostic, the filename and a hash of the source
+// on the lines of its subject rather than line numbers so the baseline survives unrelated
+// edits that shift lines. Each fingerprint holds the number of times it was seen so fixing
+// one of several identical diagnostics is not hidden by the others.
+type Baseline struct {
+	Version      int            `json:"version"`
+	Fingerprints map[string]int `json:"fingerprints"`
+}
+
+// Baseline creates a new Baseline containing the fingerprints of all diagnostics.
+func (d *Diagnostics) Baseline() *Baseline {
+	b := &Baseline{
+		Version:      BaselineVersion,
+		Fingerprints: map[string]int{},
+	}
+
+	for _, diag := range d.Diags {
+		b.Fingerprints[d.Fingerprint(diag)]++
+	}
+
+	return b
+}
+
+// Exclude returns a new Diagnostics without the diagnostics recorded in the baseline.
+func (d *Diagnostics) Exclude(b *Baseline) *Diagnostics {
+	remaining := map[string]int{}
+	for fingerprint, count := range b.Fingerprints {
+		remaining[fingerprint] = count
+	}
+
+	return d.Filter(func(diag *hcl.Diagnostic) bool {
+		fingerprint := d.Fingerprint(diag)
+		if remaining[fingerprint] > 0 {
+			remaining[fingerprint]--
+			return false
+		}
+
+		return true
+	})
+}
+
+// Fingerprint returns a stable fingerprint for the diagnostic which does not depend on the
+// line numbers of its subject.
+func (d *Diagnostics) Fingerprint(diag *hcl.Diagnostic) string {
+	h := sha256.New()
+
+	id := string(CodeOf(diag))
+	if id == "" {
+		id = diag.Summary
+	}
+
+	fmt.Fprintf(h, "%s\x00", id)
+
+	if diag.Subject == nil {
+		fmt.Fprintf(h, "%s\x00", diag.Detail)
+	} else {
+		fmt.Fprintf(h, "%s\x00%s\x00", diag.Subject.Filename, d.subjectContent(diag.Subject))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// subjectContent returns the whitespace-normalized source of the lines covered by the range.
+// When the source is not available the position of the range is used instead.
+func (d *Diagnostics) subjectContent(rng *hcl.Range) string {
+	var file *hcl.File
+	if d.Spec != nil {
+		file = d.Spec.files[rng.Filename]
+	}
+
+	if file == nil || file.Bytes == nil {
+		return rng.String()
+	}
+
+	lines := []string{}
+	sc := hcl.NewRangeScanner(file.Bytes, rng.Filename, bufio.ScanLines)
+
+	for sc.Scan() {
+		lineRange := sc.Range()
+		if lineRange.Start.Line >= rng.Start.Line && lineRange.Start.Line <= rng.End.Line {
+			lines = append(lines, strings.Join(strings.Fields(string(sc.Bytes())), " "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ReadBaseline reads a Baseline previously written with Write.
+func ReadBaseline(from io.Reader) (*Baseline, error) {
+	b := &Baseline{}
+
+	if err := json.NewDecoder(from).Decode(b); err != nil {
+		return nil, fmt.Errorf("unable to read baseline: %w", err)
+	}
+
+	if b.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d", b.Version)
+	}
+
+	if b.Fingerprints == nil {
+		b.Fingerprints = map[string]int{}
+	}
+
+	return b, nil
+}
+
+// ReadBaselineFile reads a Baseline from the file at filename.
+func ReadBaselineFile(filename string) (*Baseline, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBaseline(bytes.NewReader(src))
+}
+
+// Write writes the Baseline as JSON to the provided io.Writer.
+func (b *Baseline) Write(to io.Writer) error {
+	enc := json.NewEncoder(to)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(b)
+}
+
+// WriteFile writes the Baseline to the file at filename, replacing it if it exists.
+func (b *Baseline) WriteFile(filename string) error {
+	buf := new(bytes.Buffer)
+	if err := b.Write(buf); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0600)
+}
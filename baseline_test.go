@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseline(tt *testing.T) {
+	parse := func(src string) *spec.Diagnostics {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte(src), "unit.hcl")
+
+		return s.Parse(&hcl.EvalContext{})
+	}
+
+	tt.Run("Exclude() survives edits that shift line numbers", func(t *testing.T) {
+		baseline := parse("unit {\n}\n").Baseline()
+		diags := parse("# a new comment\n\n\nunit {\n}\n")
+
+		assert.True(t, diags.HasErrors())
+		assert.Equal(t, 0, diags.Exclude(baseline).Len())
+	})
+
+	tt.Run("Exclude() reports new diagnostics", func(t *testing.T) {
+		baseline := parse("unit {\n  name = \"Medic 1\"\n}\n").Baseline()
+		diags := parse("unit {\n}\n")
+
+		assert.Equal(t, 1, diags.Exclude(baseline).Len())
+	})
+
+	tt.Run("a baseline can be written and read back", func(t *testing.T) {
+		baseline := parse("unit {\n}\n").Baseline()
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, baseline.Write(b))
+
+		read, err := spec.ReadBaseline(b)
+		assert.NoError(t, err)
+		assert.Equal(t, baseline.Fingerprints, read.Fingerprints)
+	})
+
+	tt.Run("ReadBaseline() rejects unknown versions", func(t *testing.T) {
+		_, err := spec.ReadBaseline(bytes.NewBufferString(`{"version": 99}`))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// ChangeSet holds the new contents of files changed by a refactoring such as Rename. The
+// changes are not applied until Apply is called, allowing them to be previewed or sent to an
+// editor instead.
+type ChangeSet struct {
+	files map[string][]byte
+}
+
+func newChangeSet() *ChangeSet {
+	return &ChangeSet{files: map[string][]byte{}}
+}
+
+// Filenames returns the names of the changed files in alphabetical order.
+func (c *ChangeSet) Filenames() []string {
+	names := make([]string, 0, len(c.files))
+
+	for name := range c.files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Bytes returns the new contents of the file or nil when the file is not changed.
+func (c *ChangeSet) Bytes(filename string) []byte {
+	return c.files[filename]
+}
+
+// Len returns the number of changed files.
+func (c *ChangeSet) Len() int {
+	return len(c.files)
+}
+
+// Apply writes the new contents of each changed file in place, keeping the permissions of
+// existing files. The Spec is not updated and the files should be parsed again afterwards.
+func (c *ChangeSet) Apply() error {
+	for _, filename := range c.Filenames() {
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(filename); err == nil {
+			mode = info.Mode().Perm()
+		}
+
+		if err := ioutil.WriteFile(filename, c.files[filename], mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
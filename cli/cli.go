@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+)
+
+// exit codes returned by Run
+const (
+	ExitOK          = 0
+	ExitDiagnostics = 1
+	ExitUsage       = 2
+)
+
+// output formats for diagnostics
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Name is the name of the tool used in messages and reports.
+const Name = "spec"
+
+// command is a subcommand of the tool.
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]*command{
+	"docs":     {summary: "print Markdown reference documentation for a schema", run: docs},
+	"fmt":      {summary: "format files or check that they are formatted", run: formatFiles},
+	"init":     {summary: "write a skeleton configuration file for a schema", run: initFile},
+	"schema":   {summary: "print the JSON Schema of a schema's JSON form", run: schema},
+	"validate": {summary: "validate files against a schema", run: validate},
+}
+
+// Run runs the tool with the given arguments, excluding the name of the program, and returns
+// the exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "%s: unknown command %q\n", Name, args[0])
+		usage(stderr)
+
+		return ExitUsage
+	}
+
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func usage(to io.Writer) {
+	fmt.Fprintf(to, "usage: %s <command> [flags] [arguments]\n\ncommands:\n", Name)
+
+	for _, name := range commandNames() {
+		fmt.Fprintf(to, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+func commandNames() []string {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// resolveSchema returns the schema registered with the name. When no name is given and a
+// single schema is registered that schema is used.
+func resolveSchema(name string) (parser.NamedBlockDefinitions, error) {
+	names := spec.SchemaNames()
+
+	if name == "" && len(names) == 1 {
+		name = names[0]
+	}
+
+	defs, ok := spec.Schema(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q, registered schemas: %s", name, strings.Join(names, ", "))
+	}
+
+	return defs, nil
+}
+
+// expandFiles expands the glob patterns within the arguments into the matching filenames.
+// Arguments without glob characters are returned as is so missing files are reported.
+func expandFiles(args []string) ([]string, error) {
+	filenames := []string{}
+
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			filenames = append(filenames, arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", arg, err)
+		}
+
+		filenames = append(filenames, matches...)
+	}
+
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no files matched")
+	}
+
+	return filenames, nil
+}
+
+func validFormat(format string) bool {
+	return format == FormatText || format == FormatJSON || format == FormatSARIF
+}
+
+// writeDiagnostics writes the diagnostics in the format and returns ExitDiagnostics when they
+// contain errors.
+func writeDiagnostics(diags *spec.Diagnostics, format string, stdout, stderr io.Writer) int {
+	var err error
+
+	switch format {
+	case FormatJSON:
+		err = diags.WriteJSON(stdout)
+	case FormatSARIF:
+		err = diags.WriteSARIF(stdout, Name)
+	default:
+		err = diags.WriteText(stdout, 0, false)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	if diags.HasErrors() {
+		return ExitDiagnostics
+	}
+
+	return ExitOK
+}
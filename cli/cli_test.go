@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/cli"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type stationDef struct{}
+
+func (d *stationDef) Name() string {
+	return "station"
+}
+
+func (d *stationDef) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "station",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"name": &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+		},
+	}
+}
+
+func init() {
+	spec.RegisterSchema("stations", parser.NamedBlockDefinitions{&stationDef{}})
+}
+
+func run(args ...string) (int, string, string) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	code := cli.Run(args, stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(tt *testing.T) {
+	tt.Run("Run() prints usage without a known command", func(t *testing.T) {
+		code, _, stderr := run()
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, "validate")
+
+		code, _, stderr = run("unknown")
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, `unknown command "unknown"`)
+	})
+}
@@ -0,0 +1,10 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package cli implements the spec command-line tool. Schemas are registered from Go using
+// spec.RegisterSchema so applications build their own copy of the tool by registering their
+// schemas and calling Run from their main package, as cmd/spec does.
+package cli
@@ -0,0 +1,45 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/responserms/spec"
+)
+
+// docs writes Markdown reference documentation for a registered schema.
+func docs(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("docs", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s docs [flags]\n\nflags:\n", Name)
+		flags.PrintDefaults()
+	}
+
+	name := flags.String("schema", "", "the name of the registered schema, optional when only one is registered")
+	title := flags.String("title", "Configuration Reference", "the title of the document, omitted when empty")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	defs, err := resolveSchema(*name)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	if err := spec.New(defs).WriteMarkdown(stdout, *title); err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/responserms/spec/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocs(tt *testing.T) {
+	tt.Run("docs writes Markdown for the registered schema", func(t *testing.T) {
+		code, stdout, _ := run("docs", "-title", "Stations")
+
+		assert.Equal(t, cli.ExitOK, code)
+		assert.True(t, strings.HasPrefix(stdout, "# Stations\n\n## `station`\n"))
+		assert.Contains(t, stdout, "| `name` | `string` | yes |")
+	})
+
+	tt.Run("docs reports unknown schemas", func(t *testing.T) {
+		code, _, stderr := run("docs", "-schema", "missing")
+
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, `unknown schema "missing"`)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/responserms/spec"
+)
+
+// formatFiles rewrites the files and globs with canonical formatting and prints the name of each
+// file changed. With -check the files are not changed and those not formatted are reported as
+// diagnostics instead.
+func formatFiles(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s fmt [flags] <file or glob>...\n\nflags:\n", Name)
+		flags.PrintDefaults()
+	}
+
+	check := flags.Bool("check", false, "report files that are not formatted rather than formatting them")
+	format := flags.String("format", FormatText, "the output format of diagnostics, one of text, json or sarif")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if !validFormat(*format) {
+		fmt.Fprintf(stderr, "%s: unknown format %q\n", Name, *format)
+		return ExitUsage
+	}
+
+	filenames, err := expandFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	// formatting does not depend on a schema
+	s := spec.NewSubset()
+
+	diags := s.Files(filenames...)
+	if diags.HasErrors() {
+		return writeDiagnostics(diags.Dedupe().Sort(), *format, stdout, stderr)
+	}
+
+	if *check {
+		return writeDiagnostics(diags.Merge(s.CheckFormat()).Dedupe().Sort(), *format, stdout, stderr)
+	}
+
+	changes, diags := s.Format()
+	if diags.HasErrors() {
+		return writeDiagnostics(diags, *format, stdout, stderr)
+	}
+
+	if err := changes.Apply(); err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	for _, filename := range changes.Filenames() {
+		fmt.Fprintln(stdout, filename)
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/responserms/spec/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFmt(tt *testing.T) {
+	dir, err := ioutil.TempDir("", "spec")
+	assert.NoError(tt, err)
+	defer os.RemoveAll(dir)
+
+	hclFile := filepath.Join(dir, "stations.hcl")
+	jsonFile := filepath.Join(dir, "stations.json")
+	formattedFile := filepath.Join(dir, "formatted.hcl")
+
+	assert.NoError(tt, ioutil.WriteFile(hclFile, []byte("station \"st12\" {\nname = \"Station 12\"\n}\n"), 0644))
+	assert.NoError(tt, ioutil.WriteFile(jsonFile, []byte(`{"station": {"st13": {"name": "Station 13"}}}`), 0644))
+	assert.NoError(tt, ioutil.WriteFile(formattedFile, []byte("station \"st14\" {\n  name = \"Station 14\"\n}\n"), 0644))
+
+	tt.Run("fmt -check reports files that are not formatted", func(t *testing.T) {
+		code, stdout, _ := run("fmt", "-check", filepath.Join(dir, "*"))
+
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stdout, "File is not formatted")
+		assert.Contains(t, stdout, "stations.hcl line 2")
+		assert.Contains(t, stdout, "stations.json line 1")
+		assert.NotContains(t, stdout, "formatted.hcl")
+	})
+
+	tt.Run("fmt formats files in place and lists them", func(t *testing.T) {
+		code, stdout, _ := run("fmt", filepath.Join(dir, "*"))
+
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Equal(t, hclFile+"\n"+jsonFile+"\n", stdout)
+
+		src, err := ioutil.ReadFile(hclFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "station \"st12\" {\n  name = \"Station 12\"\n}\n", string(src))
+
+		code, stdout, _ = run("fmt", "--check", filepath.Join(dir, "*"))
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Empty(t, stdout)
+	})
+
+	tt.Run("fmt reports files that cannot be parsed", func(t *testing.T) {
+		code, stdout, _ := run("fmt", "-format", "json", "./testdata/missing.hcl")
+
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stdout, "SPEC002")
+	})
+}
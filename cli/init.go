@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/responserms/spec"
+)
+
+// initFile writes a skeleton configuration file for a registered schema to the file given as
+// its argument, or to stdout as HCL when no file is given. The syntax of the file is determined
+// by its extension.
+func initFile(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s init [flags] [file]\n\nflags:\n", Name)
+		flags.PrintDefaults()
+	}
+
+	name := flags.String("schema", "", "the name of the registered schema, optional when only one is registered")
+	force := flags.Bool("force", false, "overwrite the file when it already exists")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	defs, err := resolveSchema(*name)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	filename := flags.Arg(0)
+
+	syntax := spec.SyntaxHCL
+	if filename != "" {
+		syntax = spec.SyntaxOf(filename)
+	}
+
+	if syntax == spec.SyntaxUnknown {
+		fmt.Fprintf(stderr, "%s: cannot determine the syntax of %q, use a .hcl or .json extension\n", Name, filename)
+		return ExitUsage
+	}
+
+	src, err := spec.New(defs).Skeleton(syntax)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	if filename == "" {
+		_, err = stdout.Write(src)
+	} else {
+		err = writeNewFile(filename, src, *force)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	return ExitOK
+}
+
+// writeNewFile writes the file, refusing to overwrite an existing file unless forced.
+func writeNewFile(filename string, src []byte, force bool) error {
+	if _, err := os.Stat(filename); err == nil && !force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", filename)
+	}
+
+	return ioutil.WriteFile(filename, src, 0644)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/responserms/spec/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(tt *testing.T) {
+	dir, err := ioutil.TempDir("", "spec")
+	if !assert.NoError(tt, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	tt.Run("init writes HCL to stdout without a file", func(t *testing.T) {
+		code, stdout, _ := run("init")
+
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Equal(t, "# station \"id\" {\n#   name = \"\"\n# }\n", stdout)
+	})
+
+	tt.Run("init writes the file in the syntax of its extension", func(t *testing.T) {
+		filename := filepath.Join(dir, "stations.json")
+
+		code, stdout, _ := run("init", filename)
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Empty(t, stdout)
+
+		src, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"//\": \"Optional: station.\"\n}\n", string(src))
+	})
+
+	tt.Run("init does not overwrite files unless forced", func(t *testing.T) {
+		filename := filepath.Join(dir, "existing.hcl")
+		assert.NoError(t, ioutil.WriteFile(filename, []byte("# existing\n"), 0644))
+
+		code, _, stderr := run("init", filename)
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stderr, "already exists")
+
+		src, _ := ioutil.ReadFile(filename)
+		assert.Equal(t, "# existing\n", string(src))
+
+		code, _, _ = run("init", "-force", filename)
+		assert.Equal(t, cli.ExitOK, code)
+
+		src, _ = ioutil.ReadFile(filename)
+		assert.Contains(t, string(src), "# station \"id\" {")
+	})
+
+	tt.Run("init reports unknown syntaxes", func(t *testing.T) {
+		code, _, stderr := run("init", filepath.Join(dir, "stations.txt"))
+
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, "cannot determine the syntax")
+	})
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/responserms/spec"
+)
+
+// schema writes the JSON Schema describing the JSON form of a registered schema.
+func schema(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s schema [flags]\n\nflags:\n", Name)
+		flags.PrintDefaults()
+	}
+
+	name := flags.String("schema", "", "the name of the registered schema, optional when only one is registered")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	defs, err := resolveSchema(*name)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	if err := spec.New(defs).JSONSchema().WriteJSON(stdout); err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitDiagnostics
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(tt *testing.T) {
+	tt.Run("schema writes the JSON Schema of the registered schema", func(t *testing.T) {
+		code, stdout, _ := run("schema", "-schema", "stations")
+		assert.Equal(t, cli.ExitOK, code)
+
+		out := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &out))
+		assert.Equal(t, spec.JSONSchemaDraft, out["$schema"])
+		assert.Contains(t, out["properties"], "station")
+	})
+
+	tt.Run("schema reports unknown schemas", func(t *testing.T) {
+		code, _, stderr := run("schema", "-schema", "missing")
+
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, `unknown schema "missing"`)
+	})
+}
@@ -0,0 +1,5 @@
+station "st14" {
+  name = "Station 14"
+}
+
+unit = "medic-14"
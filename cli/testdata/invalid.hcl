@@ -0,0 +1,2 @@
+station "st13" {
+}
@@ -0,0 +1,3 @@
+station "st12" {
+  name = "Station 12"
+}
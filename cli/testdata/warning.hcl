@@ -0,0 +1,4 @@
+# spec:ignore SPEC012 name is optional for now
+station "st15" {
+  name = "Station 15"
+}
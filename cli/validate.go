@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+)
+
+// validate parses the files and globs against a registered schema and writes the diagnostics.
+// The exit code is ExitDiagnostics when any error diagnostics are found.
+func validate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s validate [flags] <file or glob>...\n\nflags:\n", Name)
+		flags.PrintDefaults()
+	}
+
+	schema := flags.String("schema", "", "the name of the registered schema, optional when only one is registered")
+	format := flags.String("format", FormatText, "the output format, one of text, json or sarif")
+	strict := flags.Bool("strict", false, "report blocks and attributes not described by the schema as errors")
+	warningsAsErrors := flags.Bool("warnings-as-errors", false, "report all warnings as errors")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if !validFormat(*format) {
+		fmt.Fprintf(stderr, "%s: unknown format %q\n", Name, *format)
+		return ExitUsage
+	}
+
+	defs, err := resolveSchema(*schema)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	filenames, err := expandFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", Name, err)
+		return ExitUsage
+	}
+
+	s := spec.New(defs)
+	s.UseStrict(*strict)
+
+	if *warningsAsErrors {
+		s.UsePolicy(spec.WarningsAsErrorsPolicy())
+	}
+
+	diags := s.Files(filenames...)
+	diags = diags.Merge(s.Parse(&hcl.EvalContext{})).Dedupe().Sort()
+
+	return writeDiagnostics(diags, *format, stdout, stderr)
+}
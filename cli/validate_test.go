@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cli_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(tt *testing.T) {
+	tt.Run("validate exits successfully for valid files", func(t *testing.T) {
+		code, stdout, _ := run("validate", "-schema", "stations", "./testdata/valid.hcl")
+
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Empty(t, stdout)
+	})
+
+	tt.Run("validate reports errors as text", func(t *testing.T) {
+		code, stdout, _ := run("validate", "./testdata/*.hcl")
+
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stdout, "Missing required argument")
+		assert.Contains(t, stdout, "on testdata/invalid.hcl line 1")
+	})
+
+	tt.Run("validate writes JSON and SARIF", func(t *testing.T) {
+		code, stdout, _ := run("validate", "-format", "json", "./testdata/invalid.hcl")
+		assert.Equal(t, cli.ExitDiagnostics, code)
+
+		out := map[string][]map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &out))
+		assert.Equal(t, string(spec.CodeMissingRequiredArgument), out["diagnostics"][0]["code"])
+
+		code, stdout, _ = run("validate", "--format=sarif", "./testdata/invalid.hcl")
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stdout, `"version": "2.1.0"`)
+		assert.Contains(t, stdout, `"ruleId": "SPEC012"`)
+	})
+
+	tt.Run("validate reports unsupported content when strict", func(t *testing.T) {
+		code, _, _ := run("validate", "./testdata/extra.hcl")
+		assert.Equal(t, cli.ExitOK, code)
+
+		code, stdout, _ := run("validate", "--strict", "./testdata/extra.hcl")
+		assert.Equal(t, cli.ExitDiagnostics, code)
+		assert.Contains(t, stdout, "Unsupported argument")
+	})
+
+	tt.Run("validate fails on warnings when warnings are errors", func(t *testing.T) {
+		code, stdout, _ := run("validate", "./testdata/warning.hcl")
+		assert.Equal(t, cli.ExitOK, code)
+		assert.Contains(t, stdout, "Warning")
+
+		code, _, _ = run("validate", "--warnings-as-errors", "./testdata/warning.hcl")
+		assert.Equal(t, cli.ExitDiagnostics, code)
+	})
+
+	tt.Run("validate reports usage errors", func(t *testing.T) {
+		code, _, stderr := run("validate", "-schema", "missing", "./testdata/valid.hcl")
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, `unknown schema "missing"`)
+
+		code, _, stderr = run("validate", "-format", "xml", "./testdata/valid.hcl")
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, `unknown format "xml"`)
+
+		code, _, stderr = run("validate", "./testdata/*.json")
+		assert.Equal(t, cli.ExitUsage, code)
+		assert.Contains(t, stderr, "no files matched")
+	})
+}
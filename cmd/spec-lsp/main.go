@@ -0,0 +1,44 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command spec-lsp is a template for the language server of an application using this module. It
+// runs a Language Server Protocol server over stdin and stdout for configuration files described
+// by the schema registered with spec.RegisterSchema under the name given by -schema.
+//
+// This command registers no schemas, so as shipped it always exits with an unknown schema error.
+// Copy this package into the application, register its schemas with spec.RegisterSchema at the
+// start of main and build that copy, which editors then run as:
+//
+//	spec-lsp -schema response
+//
+// Applications embedding the server in another program use lsp.NewServer directly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/lsp"
+)
+
+func main() {
+	schema := flag.String("schema", "", "the name of the registered schema describing the documents")
+	flag.Parse()
+
+	defs, ok := spec.Schema(*schema)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "spec-lsp: unknown schema %q, registered schemas: %s\n", *schema, strings.Join(spec.SchemaNames(), ", "))
+		os.Exit(1)
+	}
+
+	if err := lsp.NewServer(defs).Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "spec-lsp: %s\n", err)
+		os.Exit(1)
+	}
+}
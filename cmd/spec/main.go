@@ -0,0 +1,37 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command spec is a template for the command-line tool of an application using this module. It
+// validates configuration files against a schema registered with spec.RegisterSchema, formats
+// them, documents the schema as Markdown or JSON Schema and writes skeleton configuration files.
+//
+// This command registers no schemas, so as shipped only "spec fmt" works and every command
+// given -schema fails with an unknown schema error. Copy this package into the application,
+// register its schemas with spec.RegisterSchema before calling cli.Run and build that copy:
+//
+//	func main() {
+//		spec.RegisterSchema("response", response.Schema)
+//		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+//	}
+//
+// The copy may then be used as:
+//
+//	spec init -schema response ./config/response.hcl
+//	spec validate -schema response -format sarif ./config/*.hcl
+//	spec fmt -check ./config/*.hcl
+//	spec schema -schema response > response.schema.json
+//	spec docs -schema response > docs/configuration.md
+package main
+
+import (
+	"os"
+
+	"github.com/responserms/spec/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
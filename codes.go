@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec/parser"
+)
+
+// Code is a short, stable identifier for a class of diagnostic such as SPEC001. Codes
+// allow tooling and end-users to refer to a diagnostic without matching on its summary
+// which may change between releases.
+type Code string
+
+// diagnostic codes for diagnostics produced by this package and by the hcl package
+const (
+	CodeCannotDetermineFileType Code = "SPEC001"
+	CodeFailedToReadFile        Code = "SPEC002"
+	CodeUnusedSuppression       Code = "SPEC003"
+	CodeCannotRename            Code = "SPEC004"
+	CodeInvalidName             Code = "SPEC005"
+	CodeFileNotEditable         Code = "SPEC006"
+	CodeFileNotFormatted        Code = "SPEC007"
+	CodeDeprecatedAttribute     Code = "SPEC008"
+	CodeDeprecatedBlock         Code = "SPEC009"
+
+	CodeUnsupportedArgument      Code = "SPEC010"
+	CodeUnsupportedBlockType     Code = "SPEC011"
+	CodeMissingRequiredArgument  Code = "SPEC012"
+	CodeDuplicateArgument        Code = "SPEC013"
+	CodeIncorrectAttributeType   Code = "SPEC014"
+	CodeUnsupportedAttribute     Code = "SPEC015"
+	CodeUnknownVariable          Code = "SPEC016"
+	CodeCallToUnknownFunction    Code = "SPEC017"
+	CodeArgumentOrBlockRequired  Code = "SPEC018"
+	CodeInvalidExpression        Code = "SPEC019"
+	CodeInvalidFunctionArgument  Code = "SPEC020"
+	CodeIncorrectJSONValueType   Code = "SPEC021"
+	CodeInvalidTemplateInterpVal Code = "SPEC022"
+
+	CodeCannotConvert      Code = "SPEC023"
+	CodeCannotEdit         Code = "SPEC024"
+	CodeCannotEncode       Code = "SPEC025"
+	CodeUnsupportedVersion Code = "SPEC026"
+	CodeCannotMigrate      Code = "SPEC027"
+	CodeMigrated           Code = "SPEC028"
+	CodeInvalidValue       Code = "SPEC029"
+	CodeInvalidCombination Code = "SPEC030"
+	CodeValidationFailed   Code = "SPEC031"
+	CodeInvalidValidation  Code = "SPEC032"
+	CodeUnknownReference   Code = "SPEC033"
+	CodeDuplicateReference Code = "SPEC034"
+	CodeCannotFormat       Code = "SPEC035"
+)
+
+// diagnosticCodes maps the summary of a diagnostic to its Code. Diagnostics in HCL use
+// a terse, fixed summary with the specifics placed in the detail which allows us to
+// identify the class of a diagnostic by its summary alone.
+var diagnosticCodes = map[string]Code{
+	DiagCannotDetermineFileType: CodeCannotDetermineFileType,
+	DiagFailedToReadFile:        CodeFailedToReadFile,
+	DiagUnusedSuppression:       CodeUnusedSuppression,
+	DiagCannotRename:            CodeCannotRename,
+	DiagInvalidName:             CodeInvalidName,
+	DiagFileNotEditable:         CodeFileNotEditable,
+	DiagFileNotFormatted:        CodeFileNotFormatted,
+	DiagDeprecatedAttribute:     CodeDeprecatedAttribute,
+	DiagDeprecatedBlock:         CodeDeprecatedBlock,
+
+	"Unsupported argument":                  CodeUnsupportedArgument,
+	"Unsupported block type":                CodeUnsupportedBlockType,
+	"Missing required argument":             CodeMissingRequiredArgument,
+	"Duplicate argument":                    CodeDuplicateArgument,
+	"Incorrect attribute value type":        CodeIncorrectAttributeType,
+	"Unsupported attribute":                 CodeUnsupportedAttribute,
+	"Unknown variable":                      CodeUnknownVariable,
+	"Call to unknown function":              CodeCallToUnknownFunction,
+	"Argument or block definition required": CodeArgumentOrBlockRequired,
+	"Invalid expression":                    CodeInvalidExpression,
+	"Invalid function argument":             CodeInvalidFunctionArgument,
+	"Incorrect JSON value type":             CodeIncorrectJSONValueType,
+	"Invalid template interpolation value":  CodeInvalidTemplateInterpVal,
+
+	DiagCannotConvert:      CodeCannotConvert,
+	DiagCannotEdit:         CodeCannotEdit,
+	DiagCannotEncode:       CodeCannotEncode,
+	DiagUnsupportedVersion: CodeUnsupportedVersion,
+	DiagCannotMigrate:      CodeCannotMigrate,
+	DiagMigrated:           CodeMigrated,
+
+	parser.DiagInvalidValue:       CodeInvalidValue,
+	parser.DiagInvalidCombination: CodeInvalidCombination,
+	DiagValidationFailed:          CodeValidationFailed,
+	DiagInvalidValidation:         CodeInvalidValidation,
+	DiagUnknownReference:          CodeUnknownReference,
+	DiagDuplicateReference:        CodeDuplicateReference,
+	DiagCannotFormat:              CodeCannotFormat,
+}
+
+// RegisterCode associates the given Code with all diagnostics using the given summary. This
+// allows BlockDefinition's that return their own diagnostics to participate in filtering and
+// the other code-based features of Diagnostics. RegisterCode is not safe for concurrent use
+// and should be called while setting up the application, before parsing.
+func RegisterCode(code Code, summary string) {
+	diagnosticCodes[summary] = code
+}
+
+// CodeOf returns the Code associated with the diagnostic. If the diagnostic has not been
+// associated with a Code an empty Code will be returned.
+func CodeOf(diag *hcl.Diagnostic) Code {
+	if diag == nil {
+		return ""
+	}
+
+	return diagnosticCodes[diag.Summary]
+}
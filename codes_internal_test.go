@@ -0,0 +1,32 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalCodes(tt *testing.T) {
+	tt.Run("RegisterCode() associates a code with a summary", func(t *testing.T) {
+		// the registered codes are shared by all tests so they are restored afterwards
+		registered := map[string]Code{}
+		for summary, code := range diagnosticCodes {
+			registered[summary] = code
+		}
+
+		defer func() {
+			diagnosticCodes = registered
+		}()
+
+		RegisterCode("TEST001", "A registered test summary")
+
+		assert.Equal(t, Code("TEST001"), CodeOf(&hcl.Diagnostic{Summary: "A registered test summary"}))
+	})
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOf(tt *testing.T) {
+	tt.Run("CodeOf() returns the code for known summaries", func(t *testing.T) {
+		diag := &hcl.Diagnostic{Summary: "Unsupported block type"}
+
+		assert.Equal(t, spec.CodeUnsupportedBlockType, spec.CodeOf(diag))
+	})
+
+	tt.Run("CodeOf() returns an empty code for unknown summaries", func(t *testing.T) {
+		assert.Equal(t, spec.Code(""), spec.CodeOf(&hcl.Diagnostic{Summary: "Something unexpected"}))
+		assert.Equal(t, spec.Code(""), spec.CodeOf(nil))
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+)
+
+// Compatibility holds the differences between two versions of a schema, see
+// parser.CompareSchemas for how each change is classified.
+type Compatibility struct {
+	Changes []*parser.SchemaChange
+}
+
+// Compare compares the registered BlockDefinition's of an older Spec with those of a newer one.
+func Compare(from, to *Spec) *Compatibility {
+	return CompareSpecs(from.Build(), to.Build())
+}
+
+// CompareSpecs compares an older hcldec.Spec with a newer one, such as the result of Build for
+// two versions of a schema.
+func CompareSpecs(from, to hcldec.Spec) *Compatibility {
+	return &Compatibility{
+		Changes: parser.CompareSchemas(parser.Introspect(from), parser.Introspect(to)),
+	}
+}
+
+// Breaking returns the breaking changes.
+func (c *Compatibility) Breaking() []*parser.SchemaChange {
+	return c.filter(true)
+}
+
+// NonBreaking returns the changes that are not breaking.
+func (c *Compatibility) NonBreaking() []*parser.SchemaChange {
+	return c.filter(false)
+}
+
+// HasBreaking returns true when at least one of the changes is breaking.
+func (c *Compatibility) HasBreaking() bool {
+	return len(c.Breaking()) > 0
+}
+
+func (c *Compatibility) filter(breaking bool) []*parser.SchemaChange {
+	res := []*parser.SchemaChange{}
+
+	for _, change := range c.Changes {
+		if change.Breaking == breaking {
+			res = append(res, change)
+		}
+	}
+
+	return res
+}
+
+// WriteChangelog writes the changes as a Markdown changelog to the provided io.Writer, listing
+// the breaking changes before all others.
+func (c *Compatibility) WriteChangelog(to io.Writer, title string) error {
+	w := bufio.NewWriter(to)
+
+	if title != "" {
+		fmt.Fprintf(w, "# %s\n\n", title)
+	}
+
+	if len(c.Changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return w.Flush()
+	}
+
+	sections := []struct {
+		heading string
+		changes []*parser.SchemaChange
+	}{
+		{"Breaking changes", c.Breaking()},
+		{"Other changes", c.NonBreaking()},
+	}
+
+	first := true
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+
+		if !first {
+			fmt.Fprintln(w)
+		}
+
+		first = false
+		fmt.Fprintf(w, "## %s\n\n", section.heading)
+
+		for _, change := range section.changes {
+			fmt.Fprintf(w, "- `%s`: %s\n", change.Path, change.Message)
+		}
+	}
+
+	return w.Flush()
+}
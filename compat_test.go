@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type stationV2Schema struct{}
+
+func (s *stationV2Schema) Name() string {
+	return "station"
+}
+
+func (s *stationV2Schema) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "station",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"name":    &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+			"channel": &hcldec.AttrSpec{Name: "channel", Type: cty.String},
+			"zone":    &hcldec.AttrSpec{Name: "zone", Type: cty.String, Required: true},
+		},
+	}
+}
+
+func TestCompare(tt *testing.T) {
+	v1 := spec.NewSubset(&stationSchema{})
+	v2 := spec.NewSubset(&stationV2Schema{}, &radioSchema{})
+
+	tt.Run("Compare() classifies the changes between two specs", func(t *testing.T) {
+		compat := spec.Compare(v1, v2)
+
+		assert.True(t, compat.HasBreaking())
+		assert.Len(t, compat.Changes, 4)
+		assert.Len(t, compat.Breaking(), 2)
+		assert.Len(t, compat.NonBreaking(), 2)
+	})
+
+	tt.Run("CompareSpecs() finds no changes for the same spec", func(t *testing.T) {
+		compat := spec.CompareSpecs(v1.Build(), v1.Build())
+
+		assert.False(t, compat.HasBreaking())
+		assert.Empty(t, compat.Changes)
+
+		buf := new(bytes.Buffer)
+		assert.NoError(t, compat.WriteChangelog(buf, ""))
+		assert.Equal(t, "No changes.\n", buf.String())
+	})
+
+	tt.Run("WriteChangelog() lists breaking changes first", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, spec.Compare(v1, v2).WriteChangelog(buf, "Changes"))
+
+		assert.Equal(t, "# Changes\n\n"+
+			"## Breaking changes\n\n"+
+			"- `station.*.unit`: The block was removed.\n"+
+			"- `station.*.zone`: The required attribute was added.\n\n"+
+			"## Other changes\n\n"+
+			"- `radio`: The block was added.\n"+
+			"- `station.*.channel`: The type changed from number to string.\n", buf.String())
+	})
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// CandidateKind is the kind of a completion Candidate.
+type CandidateKind int
+
+// completion candidate kinds
+const (
+	CandidateBlock CandidateKind = iota + 1
+	CandidateAttribute
+	CandidateValue
+	CandidateVariable
+	CandidateFunction
+)
+
+// Candidate is a single completion candidate. Label is the name shown to the user, Detail
+// describes its type or signature and Insert is the text to be inserted when it is chosen.
+type Candidate struct {
+	Label  string
+	Kind   CandidateKind
+	Detail string
+	Insert string
+}
+
+// Complete returns the completion candidates at the given position within a parsed file. Within
+// a body the candidates are the blocks and the attributes not yet set, while within an attribute
+// value they are the known values for the expected type, variables and functions. When completing
+// a traversal, such as "station.st12.", the candidates are the attributes of the value it refers to.
+//
+// Variables and functions are those injected during the last call to Parse. Nil is returned when
+// the file has not been parsed.
+func (s *Spec) Complete(filename string, pos hcl.Pos) []*Candidate {
+	loc := s.At(filename, pos)
+	if loc == nil {
+		return nil
+	}
+
+	var res []*Candidate
+
+	if src := s.migratedFile(filename).Bytes; inExpression(loc, src) {
+		res = s.completeExpression(loc, src)
+	} else {
+		res = s.completeBody(loc)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Kind != res[j].Kind {
+			return res[i].Kind < res[j].Kind
+		}
+
+		return res[i].Label < res[j].Label
+	})
+
+	return res
+}
+
+// inExpression returns true when the location is within the value of an attribute. The source
+// is also inspected as an attribute being written is often not yet valid syntax.
+func inExpression(loc *Location, src []byte) bool {
+	if loc.Attribute != nil && loc.Pos.Byte > loc.Attribute.NameRange.End.Byte {
+		return true
+	}
+
+	if strings.Contains(traversalBefore(src, loc.Pos.Byte), ".") {
+		return true
+	}
+
+	line := src[:loc.Pos.Byte]
+	if i := bytes.LastIndexByte(line, '\n'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	return bytes.ContainsAny(line, "=:")
+}
+
+// completeBody returns the blocks and unset attributes allowed in the body at the location.
+func (s *Spec) completeBody(loc *Location) []*Candidate {
+	res := []*Candidate{}
+
+	if loc.BodySpec == nil {
+		return res
+	}
+
+	var body hcl.Body = s.migratedFile(loc.Filename).Body
+	if block := loc.Block(); block != nil {
+		body = block.Body
+	}
+
+	schema := hcldec.ImpliedSchema(loc.BodySpec)
+	content, _, _ := body.PartialContent(schema)
+	attrs := parser.Introspect(loc.BodySpec).Attributes
+
+	for _, block := range schema.Blocks {
+		insert := block.Type
+		for range block.LabelNames {
+			insert += ` ""`
+		}
+
+		res = append(res, &Candidate{
+			Label:  block.Type,
+			Kind:   CandidateBlock,
+			Detail: blockDetail(block),
+			Insert: insert + " {}",
+		})
+	}
+
+	for _, attr := range schema.Attributes {
+		if content != nil && content.Attributes[attr.Name] != nil {
+			continue
+		}
+
+		detail := "attribute"
+		if a, ok := attrs[attr.Name]; ok {
+			detail = a.Type.FriendlyName()
+		}
+
+		if attr.Required {
+			detail += ", required"
+		}
+
+		res = append(res, &Candidate{
+			Label:  attr.Name,
+			Kind:   CandidateAttribute,
+			Detail: detail,
+			Insert: attr.Name + " = ",
+		})
+	}
+
+	return res
+}
+
+// completeValues returns the values accepted by the attribute at the location, as listed by the
+// parser.Rule's of its registration, and the labels of the blocks it may refer to.
+func (s *Spec) completeValues(loc *Location) []*Candidate {
+	res := []*Candidate{}
+
+	if loc.Attribute == nil || len(loc.SchemaPath) == 0 {
+		return res
+	}
+
+	reg := s.registrationFor(loc.SchemaPath[0])
+	if reg == nil {
+		return res
+	}
+
+	if r, ok := reg.Definition.(parser.Referencer); ok {
+		for _, ref := range r.References() {
+			if ref.Attribute != strings.Join(loc.SchemaPath, ".") {
+				continue
+			}
+
+			for _, label := range referenceLabels(s.referenceTargets(ref.Target)) {
+				insert := fmt.Sprintf("%q", label)
+				res = append(res, &Candidate{Label: insert, Kind: CandidateValue, Detail: ref.Target, Insert: insert})
+			}
+		}
+	}
+
+	v, ok := reg.Definition.(parser.Validator)
+	if !ok {
+		return res
+	}
+
+	path := strings.Join(loc.SchemaPath[:len(loc.SchemaPath)-1], ".")
+
+	for _, rule := range v.Rules() {
+		if rule.Path != path || rule.Attribute != loc.AttributeName {
+			continue
+		}
+
+		for _, val := range rule.Values {
+			insert := string(hclwrite.TokensForValue(val).Bytes())
+			res = append(res, &Candidate{Label: insert, Kind: CandidateValue, Detail: val.Type().FriendlyName(), Insert: insert})
+		}
+	}
+
+	return res
+}
+
+// completeExpression returns the candidates for the expression being written at the location.
+func (s *Spec) completeExpression(loc *Location, src []byte) []*Candidate {
+	res := []*Candidate{}
+	prefix := traversalBefore(src, loc.Pos.Byte)
+
+	// complete the attributes of the value referred to by the traversal
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		val, ok := s.lookup(prefix[:i])
+		if !ok {
+			return res
+		}
+
+		val, marks := val.Unmark()
+		if !val.IsKnown() || val.IsNull() {
+			return res
+		}
+
+		// the keys of a map are part of its value, so those of sensitive maps and values are not
+		// offered, while the attributes of objects are always offered
+		_, sensitive := marks[parser.Sensitive]
+
+		if ty := val.Type(); ty.IsObjectType() || (ty.IsMapType() && !sensitive) {
+			for it := val.ElementIterator(); it.Next(); {
+				key, elem := it.Element()
+				if ty.IsMapType() && elem.HasMark(parser.Sensitive) {
+					continue
+				}
+
+				res = append(res, &Candidate{
+					Label:  key.AsString(),
+					Kind:   CandidateVariable,
+					Detail: elem.Type().FriendlyName(),
+					Insert: key.AsString(),
+				})
+			}
+		}
+
+		return res
+	}
+
+	if loc.Type == cty.Bool {
+		for _, v := range []string{"true", "false"} {
+			res = append(res, &Candidate{Label: v, Kind: CandidateValue, Detail: "bool", Insert: v})
+		}
+	}
+
+	res = append(res, s.completeValues(loc)...)
+
+	if s.ctx == nil {
+		return res
+	}
+
+	for name, val := range s.ctx.Variables {
+		res = append(res, &Candidate{
+			Label:  name,
+			Kind:   CandidateVariable,
+			Detail: val.Type().FriendlyName(),
+			Insert: name,
+		})
+	}
+
+	for name, fn := range s.ctx.Functions {
+		res = append(res, &Candidate{
+			Label:  name,
+			Kind:   CandidateFunction,
+			Detail: FunctionSignature(name, fn),
+			Insert: name + "(",
+		})
+	}
+
+	return res
+}
+
+// lookup resolves the dot-separated traversal against the variables injected during the last
+// call to Parse.
+func (s *Spec) lookup(traversal string) (cty.Value, bool) {
+	if s.ctx == nil {
+		return cty.NilVal, false
+	}
+
+	parts := strings.Split(traversal, ".")
+
+	val, ok := s.ctx.Variables[parts[0]]
+	if !ok {
+		return cty.NilVal, false
+	}
+
+	return traverseValue(val, parts[1:])
+}
+
+// traversalBefore returns the traversal, such as "station.st12.na", ending at the offset. The
+// source is used directly as an incomplete traversal is not valid syntax.
+func traversalBefore(src []byte, offset int) string {
+	if offset > len(src) {
+		offset = len(src)
+	}
+
+	start := offset
+	for start > 0 && isTraversalByte(src[start-1]) {
+		start--
+	}
+
+	return string(src[start:offset])
+}
+
+func isTraversalByte(b byte) bool {
+	return b == '_' || b == '-' || b == '.' ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// blockDetail describes the block and its labels.
+func blockDetail(block hcl.BlockHeaderSchema) string {
+	if len(block.LabelNames) == 0 {
+		return "block"
+	}
+
+	return fmt.Sprintf("block with labels: %s", strings.Join(block.LabelNames, ", "))
+}
+
+// FunctionSignature returns the signature of the function such as "upper(str string)".
+func FunctionSignature(name string, fn function.Function) string {
+	params := []string{}
+
+	for _, param := range fn.Params() {
+		params = append(params, fmt.Sprintf("%s %s", param.Name, param.Type.FriendlyName()))
+	}
+
+	if param := fn.VarParam(); param != nil {
+		params = append(params, fmt.Sprintf("%s... %s", param.Name, param.Type.FriendlyName()))
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func candidateLabels(candidates []*spec.Candidate) []string {
+	labels := []string{}
+	for _, candidate := range candidates {
+		labels = append(labels, candidate.Label)
+	}
+
+	return labels
+}
+
+type ruledStationSchema struct {
+	stationSchema
+}
+
+func (s *ruledStationSchema) Rules() []*parser.Rule {
+	return []*parser.Rule{
+		parser.Enum("station.*.channel", cty.NumberIntVal(12), cty.NumberIntVal(13)),
+	}
+}
+
+func TestComplete(tt *testing.T) {
+	s := spec.NewSubset(&stationSchema{})
+	assert.False(tt, s.Files("./testdata/stations/stations.hcl").HasErrors())
+	s.ParseSource([]byte("station \"st15\" {\n  name = \"Station 15\"\n  \n}\n"), "partial.hcl")
+
+	ctx := &hcl.EvalContext{
+		Functions: map[string]function.Function{"upper": stdlib.UpperFunc},
+	}
+	s.Parse(ctx)
+
+	tt.Run("Complete() returns nil for files that were not parsed", func(t *testing.T) {
+		assert.Nil(t, s.Complete("./testdata/stations/missing.hcl", hcl.Pos{Line: 1, Column: 1}))
+	})
+
+	tt.Run("Complete() lists the root blocks", func(t *testing.T) {
+		res := s.Complete("partial.hcl", hcl.Pos{Line: 5, Column: 1})
+
+		assert.Equal(t, []string{"station"}, candidateLabels(res))
+		assert.Equal(t, spec.CandidateBlock, res[0].Kind)
+		assert.Equal(t, "block with labels: id", res[0].Detail)
+		assert.Equal(t, `station "" {}`, res[0].Insert)
+	})
+
+	tt.Run("Complete() lists the blocks and unset attributes of a body", func(t *testing.T) {
+		res := s.Complete("partial.hcl", hcl.Pos{Line: 3, Column: 3})
+
+		assert.Equal(t, []string{"unit", "channel"}, candidateLabels(res))
+		assert.Equal(t, spec.CandidateAttribute, res[1].Kind)
+		assert.Equal(t, "number", res[1].Detail)
+		assert.Equal(t, "channel = ", res[1].Insert)
+	})
+
+	tt.Run("Complete() lists the variables and functions within an expression", func(t *testing.T) {
+		res := s.Complete("./testdata/stations/stations.hcl", hcl.Pos{Line: 6, Column: 16})
+
+		assert.Equal(t, []string{"station", "upper"}, candidateLabels(res))
+		assert.Equal(t, spec.CandidateFunction, res[1].Kind)
+		assert.Equal(t, "upper(str string)", res[1].Detail)
+	})
+
+	tt.Run("Complete() lists the attributes of the value a traversal refers to", func(t *testing.T) {
+		res := s.Complete("./testdata/stations/stations.hcl", hcl.Pos{Line: 6, Column: 39})
+
+		assert.Equal(t, []string{"st12", "st13", "st15"}, candidateLabels(res))
+		assert.Equal(t, spec.CandidateVariable, res[0].Kind)
+	})
+
+	tt.Run("Complete() lists the values allowed by the rules of the attribute", func(t *testing.T) {
+		s := spec.NewSubset(&ruledStationSchema{})
+		s.ParseSource([]byte("station \"st15\" {\n  name    = \"Station 15\"\n  channel = 15\n}\n"), "ruled.hcl")
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.Equal(t, []spec.Code{spec.CodeInvalidValue}, diagnosticCodes(diags))
+
+		res := s.Complete("ruled.hcl", hcl.Pos{Line: 3, Column: 13})
+
+		assert.Subset(t, candidateLabels(res), []string{"12", "13"})
+		assert.Equal(t, spec.CandidateValue, res[0].Kind)
+	})
+
+	tt.Run("Complete() lists the attributes of sensitive objects but not the keys of sensitive maps", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte("station \"st12\" {\n  name = secrets.token\n  channel = vault.x\n}\n"), "sensitive.hcl")
+		s.Parse(&hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				"secrets": cty.ObjectVal(map[string]cty.Value{
+					"token": cty.StringVal("s3cret"),
+				}).Mark(parser.Sensitive),
+				"vault": cty.MapVal(map[string]cty.Value{
+					"x": cty.NumberIntVal(12),
+				}).Mark(parser.Sensitive),
+			},
+		})
+
+		assert.Equal(t, []string{"token"}, candidateLabels(s.Complete("sensitive.hcl", hcl.Pos{Line: 2, Column: 18})))
+		assert.Empty(t, s.Complete("sensitive.hcl", hcl.Pos{Line: 3, Column: 19}))
+	})
+
+	tt.Run("Complete() accepts offsets beyond the end of the file", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			s.Complete("partial.hcl", hcl.Pos{Line: 1, Column: 1, Byte: 5000})
+		})
+	})
+}
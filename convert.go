@@ -0,0 +1,730 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// diagnostic messages
+const (
+	DiagCannotConvert            = "File cannot be converted"
+	DiagCannotConvertNotParsed   = "The file %q has not been parsed."
+	DiagCannotConvertSyntax      = "Files can only be converted to HCL or JSON."
+	DiagCannotConvertInvalidJSON = "The file is not valid JSON: %s."
+	DiagCannotConvertBlock       = "The %q block must be a JSON object or an array of objects."
+	DiagCannotConvertDuplicate   = "The property %q is repeated but is not a block. Only block properties may be repeated."
+	DiagCannotConvertName        = "The property %q is not a valid HCL attribute name. Attribute names must start with a letter and may contain letters, digits, underscores and dashes."
+)
+
+// Convert returns the parsed file rewritten in the given Syntax, such as to store a JSON file
+// produced by another application as HCL. A file already in the Syntax is formatted instead.
+//
+// Comments are kept where possible. HCL comments are joined into the "//" property of the JSON
+// object for the body containing them, while "//" properties are written as comments at the
+// start of the HCL body. Within JSON the registered hcldec.Spec decides whether a property is a
+// block, and how many of its nested objects are labels, or an attribute with an object value.
+//
+// Attribute values that can be evaluated without variables or functions are written as values
+// while all other expressions are written as template strings, and templates are converted
+// between the two syntaxes part by part.
+func (s *Spec) Convert(filename string, syntax Syntax) ([]byte, *Diagnostics) {
+	file, ok := s.files[filename]
+	if !ok || file == nil {
+		return nil, s.diagnostics(convertError(nil, fmt.Sprintf(DiagCannotConvertNotParsed, filename)))
+	}
+
+	from := SyntaxOf(filename)
+	if from == syntax {
+		res, diags := format(file.Bytes, filename, syntax)
+		return res, s.diagnostics(diags)
+	}
+
+	switch {
+	case syntax == SyntaxJSON && isHCLBody(file.Body):
+		res, diags := convertToJSON(file.Body.(*hclsyntax.Body), file.Bytes, filename)
+		return res, s.diagnostics(diags)
+	case syntax == SyntaxHCL && from == SyntaxJSON:
+		res, diags := convertToHCL(file.Bytes, filename, parser.Introspect(s.Build()))
+		return res, s.diagnostics(diags)
+	default:
+		return nil, s.diagnostics(convertError(&hcl.Range{Filename: filename}, DiagCannotConvertSyntax))
+	}
+}
+
+func convertError(subject *hcl.Range, detail string) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagCannotConvert,
+			Detail:   detail,
+			Subject:  subject,
+		},
+	}
+}
+
+// jsonObject is a JSON object that keeps the order of its properties.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]interface{}{}}
+}
+
+func (o *jsonObject) set(key string, val interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+
+	o.values[key] = val
+}
+
+// jsonRepeated holds the values of a property repeated within a JSON object, in order. HCL allows
+// properties to be repeated for blocks so each value is converted as blocks of its own.
+type jsonRepeated []interface{}
+
+// add sets the property, collecting the values of a repeated property into a jsonRepeated.
+func (o *jsonObject) add(key string, val interface{}) {
+	prev, ok := o.values[key]
+	if !ok {
+		o.set(key, val)
+		return
+	}
+
+	if rep, ok := prev.(jsonRepeated); ok {
+		o.values[key] = append(rep, val)
+		return
+	}
+
+	o.values[key] = jsonRepeated{prev, val}
+}
+
+// MarshalJSON writes the properties in order without escaping HTML characters.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := encodeJSON(buf, key); err != nil {
+			return nil, err
+		}
+
+		buf.WriteByte(':')
+
+		if err := encodeJSON(buf, o.values[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// encodeJSON writes the value to the buffer without escaping HTML characters.
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	// Encode terminates each value with a newline
+	buf.Truncate(buf.Len() - 1)
+
+	return nil
+}
+
+// decodeJSON decodes the src keeping the order of object properties. Numbers are decoded as
+// json.Number so they are written exactly as they appear and the values of repeated properties
+// are collected into a jsonRepeated.
+func decodeJSON(src []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	var decode func() (interface{}, error)
+	decode = func() (interface{}, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			obj := newJSONObject()
+
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				val, err := decode()
+				if err != nil {
+					return nil, err
+				}
+
+				obj.add(key.(string), val)
+			}
+
+			_, err := dec.Token()
+
+			return obj, err
+		case json.Delim('['):
+			arr := []interface{}{}
+
+			for dec.More() {
+				val, err := decode()
+				if err != nil {
+					return nil, err
+				}
+
+				arr = append(arr, val)
+			}
+
+			_, err := dec.Token()
+
+			return arr, err
+		default:
+			return tok, nil
+		}
+	}
+
+	return decode()
+}
+
+// convertToJSON converts the HCL body of the file to JSON.
+func convertToJSON(body *hclsyntax.Body, src []byte, filename string) ([]byte, hcl.Diagnostics) {
+	c := &jsonConverter{src: src, comments: map[*hclsyntax.Body][]string{}}
+
+	tokens, _ := hclsyntax.LexConfig(src, filename, hcl.InitialPos)
+	for _, tok := range tokens {
+		if tok.Type == hclsyntax.TokenComment {
+			inner := innermostBody(body, tok.Range.Start)
+			c.comments[inner] = append(c.comments[inner], commentText(tok.Bytes))
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(c.body(body)); err != nil {
+		return nil, convertError(&hcl.Range{Filename: filename}, err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// jsonConverter converts native HCL to JSON.
+type jsonConverter struct {
+	src      []byte
+	comments map[*hclsyntax.Body][]string
+}
+
+func (c *jsonConverter) body(body *hclsyntax.Body) *jsonObject {
+	obj := newJSONObject()
+
+	if comments := c.comments[body]; len(comments) > 0 {
+		obj.set(jsonSchemaComment, strings.Join(comments, "\n"))
+	}
+
+	// attributes and blocks are written in the order they appear
+	items := []hclsyntax.Node{}
+	for _, attr := range body.Attributes {
+		items = append(items, attr)
+	}
+
+	for _, block := range body.Blocks {
+		items = append(items, block)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Range().Start.Byte < items[j].Range().Start.Byte
+	})
+
+	for _, item := range items {
+		switch item := item.(type) {
+		case *hclsyntax.Attribute:
+			obj.set(item.Name, c.expression(item.Expr))
+		case *hclsyntax.Block:
+			container := obj
+			key := item.Type
+
+			for _, label := range item.Labels {
+				child, ok := container.values[key].(*jsonObject)
+				if !ok {
+					child = newJSONObject()
+					container.set(key, child)
+				}
+
+				container = child
+				key = label
+			}
+
+			nested := c.body(item.Body)
+
+			switch existing := container.values[key].(type) {
+			case nil:
+				container.set(key, nested)
+			case []interface{}:
+				container.set(key, append(existing, nested))
+			default:
+				container.set(key, []interface{}{existing, nested})
+			}
+		}
+	}
+
+	return obj
+}
+
+// expression returns the JSON form of the expression. Expressions that cannot be evaluated on
+// their own are written as templates.
+func (c *jsonConverter) expression(expr hclsyntax.Expression) interface{} {
+	if tmpl, ok := c.template(expr); ok {
+		return tmpl
+	}
+
+	if val, diags := expr.Value(nil); !diags.HasErrors() && val.IsWhollyKnown() {
+		return jsonValue(val)
+	}
+
+	return "${" + string(expr.Range().SliceBytes(c.src)) + "}"
+}
+
+// template returns the JSON string of a quoted template containing interpolation sequences.
+// Templates containing directives are not converted part by part.
+func (c *jsonConverter) template(expr hclsyntax.Expression) (string, bool) {
+	switch expr := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + string(expr.Wrapped.Range().SliceBytes(c.src)) + "}", true
+	case *hclsyntax.TemplateExpr:
+		if expr.IsStringLiteral() {
+			return "", false
+		}
+
+		res := ""
+
+		for _, part := range expr.Parts {
+			if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				res += escapeTemplate(lit.Val.AsString())
+				continue
+			}
+
+			before := strings.TrimRight(string(c.src[:part.Range().Start.Byte]), " \t\n~")
+			if !strings.HasSuffix(before, "${") {
+				return "", false
+			}
+
+			res += "${" + string(part.Range().SliceBytes(c.src)) + "}"
+		}
+
+		return res, true
+	default:
+		return "", false
+	}
+}
+
+// jsonValue returns the JSON form of a known value with strings escaped so they are not
+// interpreted as templates.
+func jsonValue(val cty.Value) interface{} {
+	ty := val.Type()
+
+	switch {
+	case val.IsNull():
+		return nil
+	case ty == cty.String:
+		return escapeTemplate(val.AsString())
+	case ty == cty.Number:
+		return json.Number(val.AsBigFloat().Text('f', -1))
+	case ty == cty.Bool:
+		return val.True()
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		res := []interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			res = append(res, jsonValue(elem))
+		}
+
+		return res
+	case ty.IsMapType() || ty.IsObjectType():
+		res := newJSONObject()
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			res.set(key.AsString(), jsonValue(elem))
+		}
+
+		return res
+	default:
+		return nil
+	}
+}
+
+// innermostBody returns the innermost body within the body containing the position.
+func innermostBody(body *hclsyntax.Body, pos hcl.Pos) *hclsyntax.Body {
+	for _, block := range body.Blocks {
+		if containsPos(block.Body.SrcRange, pos) {
+			return innermostBody(block.Body, pos)
+		}
+	}
+
+	return body
+}
+
+// commentText returns the text of a comment without its markers.
+func commentText(comment []byte) string {
+	text := strings.TrimSpace(string(comment))
+
+	switch {
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	case strings.HasPrefix(text, "//"):
+		text = strings.TrimPrefix(text, "//")
+	default:
+		text = strings.TrimPrefix(text, "#")
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// escapeTemplate escapes the template sequences within a literal string.
+func escapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// convertToHCL converts the JSON file to native HCL using the schema to tell blocks apart
+// from attributes.
+func convertToHCL(src []byte, filename string, schema *parser.SchemaBody) ([]byte, hcl.Diagnostics) {
+	subject := &hcl.Range{Filename: filename, Start: hcl.InitialPos, End: hcl.InitialPos}
+
+	val, err := decodeJSON(src)
+	if err != nil {
+		return nil, convertError(subject, fmt.Sprintf(DiagCannotConvertInvalidJSON, err))
+	}
+
+	obj, ok := val.(*jsonObject)
+	if !ok {
+		return nil, convertError(subject, fmt.Sprintf(DiagCannotConvertInvalidJSON, "the root must be an object"))
+	}
+
+	c := &hclConverter{buf: new(bytes.Buffer)}
+	if err := c.body(obj, schema); err != nil {
+		return nil, convertError(subject, err.Error())
+	}
+
+	return hclwrite.Format(c.buf.Bytes()), nil
+}
+
+// hclConverter converts JSON to native HCL. Indentation is left to hclwrite.
+type hclConverter struct {
+	buf *bytes.Buffer
+}
+
+// body writes the properties of the object as the contents of a body. The schema is nil when
+// the body may contain any attribute.
+func (c *hclConverter) body(obj *jsonObject, schema *parser.SchemaBody) error {
+	first := true
+
+	for _, key := range obj.keys {
+		val := obj.values[key]
+
+		if comment, ok := val.(string); ok && key == jsonSchemaComment {
+			for _, line := range strings.Split(comment, "\n") {
+				fmt.Fprintln(c.buf, strings.TrimRight("# "+line, " "))
+			}
+
+			continue
+		}
+
+		var block *parser.SchemaBlock
+		if schema != nil {
+			block = schema.Blocks[key]
+		}
+
+		if block == nil {
+			if repeatedJSON(val) {
+				return fmt.Errorf(DiagCannotConvertDuplicate, key)
+			}
+
+			if !hclsyntax.ValidIdentifier(key) {
+				return fmt.Errorf(DiagCannotConvertName, key)
+			}
+
+			fmt.Fprintf(c.buf, "%s = %s\n", key, hclValue(val))
+			first = false
+
+			continue
+		}
+
+		if err := c.blocks(block, val, []string{}, &first); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blocks writes the blocks within the value of a block property, descending into an object
+// for each label.
+func (c *hclConverter) blocks(block *parser.SchemaBlock, val interface{}, labels []string, first *bool) error {
+	if rep, ok := val.(jsonRepeated); ok {
+		for _, elem := range rep {
+			if err := c.blocks(block, elem, labels, first); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if len(labels) < len(block.LabelNames) {
+		obj, ok := val.(*jsonObject)
+		if !ok {
+			return fmt.Errorf(DiagCannotConvertBlock, block.TypeName)
+		}
+
+		for _, label := range obj.keys {
+			if err := c.blocks(block, obj.values[label], append(labels[:len(labels):len(labels)], label), first); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if arr, ok := val.([]interface{}); ok {
+		for _, elem := range arr {
+			if err := c.blocks(block, elem, labels, first); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	obj, ok := val.(*jsonObject)
+	if !ok {
+		return fmt.Errorf(DiagCannotConvertBlock, block.TypeName)
+	}
+
+	if !*first {
+		c.buf.WriteByte('\n')
+	}
+
+	*first = false
+
+	c.buf.WriteString(block.TypeName)
+	for _, label := range labels {
+		c.buf.WriteString(" " + hclString(label))
+	}
+
+	c.buf.WriteString(" {\n")
+
+	if err := c.body(obj, block.Body); err != nil {
+		return err
+	}
+
+	c.buf.WriteString("}\n")
+
+	return nil
+}
+
+// repeatedJSON returns true when the value contains a repeated property.
+func repeatedJSON(val interface{}) bool {
+	switch val := val.(type) {
+	case jsonRepeated:
+		return true
+	case []interface{}:
+		for _, elem := range val {
+			if repeatedJSON(elem) {
+				return true
+			}
+		}
+	case *jsonObject:
+		for _, elem := range val.values {
+			if repeatedJSON(elem) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hclValue returns the native syntax of a JSON value.
+func hclValue(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if val {
+			return "true"
+		}
+
+		return "false"
+	case json.Number:
+		return val.String()
+	case string:
+		return hclTemplate(val)
+	case []interface{}:
+		elems := make([]string, len(val))
+		for i, elem := range val {
+			elems[i] = hclValue(elem)
+		}
+
+		return "[" + strings.Join(elems, ", ") + "]"
+	case *jsonObject:
+		if len(val.keys) == 0 {
+			return "{}"
+		}
+
+		res := "{\n"
+		for _, key := range val.keys {
+			res += fmt.Sprintf("%s = %s\n", hclKey(key), hclValue(val.values[key]))
+		}
+
+		return res + "}"
+	default:
+		return "null"
+	}
+}
+
+// hclKey returns the object key as an identifier when it cannot be mistaken for an expression
+// or as a quoted string.
+func hclKey(key string) string {
+	switch key {
+	case "true", "false", "null", "for", "in", "if":
+		return hclString(key)
+	}
+
+	if hclsyntax.ValidIdentifier(key) && !strings.Contains(key, "-") {
+		return key
+	}
+
+	return hclString(key)
+}
+
+// hclString returns the string quoted with any template sequences escaped.
+func hclString(s string) string {
+	return "\"" + escapeQuoted(escapeTemplate(s)) + "\""
+}
+
+// hclTemplate returns the native syntax of a JSON template string. A template that consists of
+// a single interpolation is written as the bare expression.
+func hclTemplate(s string) string {
+	if strings.HasPrefix(s, "${") && !strings.HasPrefix(s, "${~") && templateSequenceEnd(s, 2) == len(s)-1 {
+		if inner := strings.TrimSpace(s[2 : len(s)-1]); !strings.HasSuffix(inner, "~") {
+			return inner
+		}
+	}
+
+	b := new(strings.Builder)
+	b.WriteByte('"')
+
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${") || strings.HasPrefix(s[i:], "%%{"):
+			b.WriteString(s[i : i+3])
+			i += 3
+		case strings.HasPrefix(s[i:], "${") || strings.HasPrefix(s[i:], "%{"):
+			end := templateSequenceEnd(s, i+2)
+			if end < 0 {
+				// an unterminated sequence is kept as literal text
+				b.WriteString(escapeQuoted(escapeTemplate(s[i:])))
+				i = len(s)
+
+				continue
+			}
+
+			b.WriteString(s[i : end+1])
+			i = end + 1
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteString(escapeQuoted(string(r)))
+			i += size
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// templateSequenceEnd returns the index of the brace closing the template sequence starting at
+// start, skipping nested braces and quoted strings, or -1 when it is not closed.
+func templateSequenceEnd(s string, start int) int {
+	depth := 1
+	quoted := false
+
+	for i := start; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case s[i] == '{':
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// escapeQuoted escapes the characters that cannot appear as is within a quoted string.
+func escapeQuoted(s string) string {
+	b := new(strings.Builder)
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+				continue
+			}
+
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+const convertHCL = `# Stations of the county
+timezone = "America/Chicago"
+
+station "st12" {
+  # the display name
+  name    = "Station ${upper("twelve")} <a&b>"
+  channel = 4
+
+  unit {
+    callsign = station.st13.name
+  }
+
+  unit {
+    callsign = "E12 $${literal}"
+  }
+}
+
+station "st13" {
+  name = "Station 13"
+}
+
+settings {
+  tags = ["a", "b"]
+  limits = {
+    calls = 10
+    "x-y" = 2
+  }
+  enabled = false
+}
+`
+
+const convertJSON = `{
+  "//": "Stations of the county",
+  "timezone": "America/Chicago",
+  "station": {
+    "st12": {
+      "//": "the display name",
+      "name": "Station ${upper(\"twelve\")} <a&b>",
+      "channel": 4,
+      "unit": [
+        {
+          "callsign": "${station.st13.name}"
+        },
+        {
+          "callsign": "E12 $${literal}"
+        }
+      ]
+    },
+    "st13": {
+      "name": "Station 13"
+    }
+  },
+  "settings": {
+    "tags": [
+      "a",
+      "b"
+    ],
+    "limits": {
+      "calls": 10,
+      "x-y": 2
+    },
+    "enabled": false
+  }
+}
+`
+
+func TestConvert(tt *testing.T) {
+	tt.Run("Convert() writes HCL as JSON keeping comments, templates and order", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{}, &settingsSchema{})
+		assert.False(t, s.ParseSource([]byte(convertHCL), "stations.hcl").HasErrors())
+
+		src, diags := s.Convert("stations.hcl", spec.SyntaxJSON)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, convertJSON, string(src))
+	})
+
+	tt.Run("Convert() writes JSON as HCL using the schema to find blocks", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{}, &settingsSchema{})
+		assert.False(t, s.ParseSource([]byte(convertJSON), "stations.json").HasErrors())
+
+		src, diags := s.Convert("stations.json", spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, convertHCL, string(src))
+	})
+
+	tt.Run("Convert() output decodes to the same value", func(t *testing.T) {
+		ctx := &hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				"station": cty.ObjectVal(map[string]cty.Value{
+					"st13": cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("Station 13")}),
+				}),
+			},
+			Functions: map[string]function.Function{"upper": stdlib.UpperFunc},
+		}
+
+		s := spec.NewSubset(&stationSchema{}, &settingsSchema{})
+		s.ParseSource([]byte(convertHCL), "stations.hcl")
+
+		src, _ := s.Convert("stations.hcl", spec.SyntaxJSON)
+
+		converted := spec.NewSubset(&stationSchema{}, &settingsSchema{})
+		converted.ParseSource(src, "stations.json")
+
+		want, diags := hcldec.Decode(s.Body(), s.Build(), ctx)
+		assert.False(t, diags.HasErrors())
+
+		got, diags := hcldec.Decode(converted.Body(), converted.Build(), ctx)
+		assert.False(t, diags.HasErrors())
+
+		assert.True(t, want.RawEquals(got))
+	})
+
+	tt.Run("Convert() writes each value of repeated block properties", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte(`{"station":{"st12":{"name":"Station 12","unit":{"callsign":"E1"},"unit":{"callsign":"E2"}}}}`), "stations.json")
+
+		src, diags := s.Convert("stations.json", spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, "station \"st12\" {\n  name = \"Station 12\"\n\n  unit {\n    callsign = \"E1\"\n  }\n\n  unit {\n    callsign = \"E2\"\n  }\n}\n", string(src))
+	})
+
+	tt.Run("Convert() reports repeated attribute properties", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte(`{"station":{"st12":{"name":"Station 12","name":"Station 13"}}}`), "stations.json")
+
+		_, diags := s.Convert("stations.json", spec.SyntaxHCL)
+		if assert.True(t, diags.HasErrors()) {
+			assert.Equal(t, spec.CodeCannotConvert, spec.CodeOf(diags.Diags[0]))
+			assert.Contains(t, diags.Diags[0].Detail, `"name"`)
+		}
+	})
+
+	tt.Run("Convert() reports properties that are not valid attribute names", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte(`{"station":{"st12":{"name":"Station 12","call sign":"E1"}}}`), "stations.json")
+
+		src, diags := s.Convert("stations.json", spec.SyntaxHCL)
+		assert.Nil(t, src)
+		if assert.True(t, diags.HasErrors()) {
+			assert.Equal(t, spec.CodeCannotConvert, spec.CodeOf(diags.Diags[0]))
+			assert.Contains(t, diags.Diags[0].Detail, `"call sign"`)
+		}
+	})
+
+	tt.Run("Convert() formats files already in the syntax", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte("station \"st12\" {\nname = \"Station 12\"\n}\n"), "stations.hcl")
+
+		src, diags := s.Convert("stations.hcl", spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, "station \"st12\" {\n  name = \"Station 12\"\n}\n", string(src))
+	})
+
+	tt.Run("Convert() reports files that cannot be converted", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.ParseSource([]byte(`{"station": {"st12": "Station 12"}}`), "stations.json")
+
+		_, diags := s.Convert("missing.hcl", spec.SyntaxJSON)
+		assert.True(t, diags.HasErrors())
+		assert.Equal(t, spec.CodeCannotConvert, spec.CodeOf(diags.Diags[0]))
+
+		_, diags = s.Convert("stations.json", spec.SyntaxUnknown)
+		assert.True(t, diags.HasErrors())
+
+		_, diags = s.Convert("stations.json", spec.SyntaxHCL)
+		if assert.True(t, diags.HasErrors()) {
+			assert.Contains(t, diags.Diags[0].Detail, `"station" block`)
+		}
+	})
+}
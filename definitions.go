@@ -0,0 +1,264 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Definition records the configuration that produced an injected variable, or a value within
+// it, along with the parser.Registration whose VariableInjector injected it. Path contains the
+// variable name followed by the attribute names or keys leading to the value, such as
+// ["station", "st12", "name"].
+//
+// Range is the range of the block or attribute that defines the value. For a variable as a
+// whole this is the first block or attribute decoded by its registration and it is empty when
+// the registration decoded nothing.
+type Definition struct {
+	Path         []string
+	Registration *parser.Registration
+	Range        hcl.Range
+}
+
+// Name returns the Path joined by dots such as "station.st12.name".
+func (d *Definition) Name() string {
+	return strings.Join(d.Path, ".")
+}
+
+// Definitions returns the definitions of the variables injected during the last call to Parse
+// and of the values within them that can be traced back to a block or attribute. Values are
+// traced when the labels of a block, optionally followed by the name of one of its attributes,
+// lead to a value within the variable.
+func (s *Spec) Definitions() []*Definition {
+	return s.definitions
+}
+
+// Definition returns the most specific definition of the value the traversal refers to or nil
+// when the traversal does not refer to an injected variable.
+func (s *Spec) Definition(traversal hcl.Traversal) *Definition {
+	path := traversalSteps(traversal)
+
+	var res *Definition
+
+	for _, def := range s.definitions {
+		if hasPathPrefix(path, def.Path) && (res == nil || len(def.Path) > len(res.Path)) {
+			res = def
+		}
+	}
+
+	return res
+}
+
+// DefinitionAt returns the definition of the variable referenced at the given position or, when
+// the position is not within a reference, the most specific definition whose range contains it.
+func (s *Spec) DefinitionAt(filename string, pos hcl.Pos) *Definition {
+	loc := s.At(filename, pos)
+	if loc == nil {
+		return nil
+	}
+
+	if loc.Traversal != nil {
+		return s.Definition(loc.Traversal)
+	}
+
+	var res *Definition
+
+	for _, def := range s.definitions {
+		if def.Range.Filename != filename || !containsPos(def.Range, loc.Pos) {
+			continue
+		}
+
+		if res == nil || rangeSize(def.Range) < rangeSize(res.Range) {
+			res = def
+		}
+	}
+
+	return res
+}
+
+// References returns every traversal within the parsed files referring to the definition or a
+// value within it, ordered by file and position. All expressions within HCL files are searched
+// while only those described by the built hcldec.Spec are searched within JSON files.
+func (s *Spec) References(def *Definition) []hcl.Traversal {
+	res := []hcl.Traversal{}
+
+	if def == nil {
+		return res
+	}
+
+	spec := s.Build()
+
+	for _, filename := range s.filenames() {
+		for _, traversal := range bodyVariables(s.migratedFile(filename).Body, spec) {
+			if hasPathPrefix(traversalSteps(traversal), def.Path) {
+				res = append(res, traversal)
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i].SourceRange(), res[j].SourceRange()
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+
+		return a.Start.Byte < b.Start.Byte
+	})
+
+	return res
+}
+
+// bodyVariables returns the traversals within the body.
+func bodyVariables(body hcl.Body, spec hcldec.Spec) []hcl.Traversal {
+	node, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return hcldec.Variables(body, spec)
+	}
+
+	res := []hcl.Traversal{}
+
+	_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		if attr, ok := n.(*hclsyntax.Attribute); ok {
+			res = append(res, attr.Expr.Variables()...)
+		}
+
+		return nil
+	})
+
+	return res
+}
+
+// define records the definitions of the variables injected during the last call to Parse.
+func (s *Spec) define() {
+	s.definitions = []*Definition{}
+
+	for _, inj := range s.registrar.Injections() {
+		root := &Definition{Path: []string{inj.Variable}, Registration: inj.Registration}
+		s.definitions = append(s.definitions, root)
+
+		spec := inj.Registration.Definition.Spec()
+		schema := hcldec.ImpliedSchema(spec)
+		blocks := parser.Introspect(spec).Blocks
+
+		for _, filename := range s.filenames() {
+			content, _, _ := s.migratedFile(filename).Body.PartialContent(schema)
+			if content == nil {
+				continue
+			}
+
+			for _, attr := range content.Attributes {
+				root.setRange(attr.Range)
+				s.defineValue(inj, []string{inj.Variable, attr.Name}, attr.Range)
+			}
+
+			for _, block := range content.Blocks {
+				root.setRange(blockRange(block))
+
+				path := append([]string{inj.Variable}, block.Labels...)
+				if len(block.Labels) > 0 {
+					s.defineValue(inj, path, blockRange(block))
+				}
+
+				sb := blocks[block.Type]
+				if sb == nil || sb.Body == nil {
+					continue
+				}
+
+				nested, _, _ := block.Body.PartialContent(hcldec.ImpliedSchema(sb.Body.Spec))
+				if nested == nil {
+					continue
+				}
+
+				for _, attr := range nested.Attributes {
+					s.defineValue(inj, append(path[:len(path):len(path)], attr.Name), attr.Range)
+				}
+			}
+		}
+	}
+}
+
+// defineValue records a definition when the path leads to a value within the injected variable.
+func (s *Spec) defineValue(inj *parser.Injection, path []string, rng hcl.Range) {
+	if _, ok := traverseValue(inj.Value, path[1:]); !ok {
+		return
+	}
+
+	s.definitions = append(s.definitions, &Definition{
+		Path:         path,
+		Registration: inj.Registration,
+		Range:        rng,
+	})
+}
+
+// setRange sets the range of the definition unless one was already found.
+func (d *Definition) setRange(rng hcl.Range) {
+	if d.Range.Filename == "" {
+		d.Range = rng
+	}
+}
+
+// filenames returns the names of the parsed files in alphabetical order.
+func (s *Spec) filenames() []string {
+	names := make([]string, 0, len(s.files))
+
+	for name := range s.files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// traverseValue follows the attribute names or keys of the path within the value. The marks of
+// the values along the path, such as parser.Sensitive, are kept on the returned value.
+func traverseValue(val cty.Value, path []string) (cty.Value, bool) {
+	marks := []cty.ValueMarks{}
+
+	for _, part := range path {
+		unmarked, valMarks := val.Unmark()
+		marks = append(marks, valMarks)
+
+		if !unmarked.IsKnown() || unmarked.IsNull() {
+			return cty.NilVal, false
+		}
+
+		ty := unmarked.Type()
+
+		switch {
+		case ty.IsObjectType() && ty.HasAttribute(part):
+			val = unmarked.GetAttr(part)
+		case ty.IsMapType() && unmarked.HasIndex(cty.StringVal(part)).True():
+			val = unmarked.Index(cty.StringVal(part))
+		default:
+			return cty.NilVal, false
+		}
+	}
+
+	return val.WithMarks(marks...), true
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// sensitiveStationSchema injects the stations marked as sensitive.
+type sensitiveStationSchema struct {
+	stationSchema
+}
+
+func (s *sensitiveStationSchema) Variables(v cty.Value) parser.InjectableVariables {
+	return parser.InjectableVariables{"station": v.Mark(parser.Sensitive)}
+}
+
+func definitionNames(defs []*spec.Definition) []string {
+	names := []string{}
+	for _, def := range defs {
+		names = append(names, def.Name())
+	}
+
+	return names
+}
+
+func TestDefinitions(tt *testing.T) {
+	const hclFile = "./testdata/stations/stations.hcl"
+	const jsonFile = "./testdata/stations/stations.json"
+
+	s := spec.NewSubset(&stationSchema{})
+	assert.False(tt, s.Files(hclFile, jsonFile).HasErrors())
+	s.Parse(&hcl.EvalContext{})
+
+	tt.Run("Definitions() records each injected variable and the values traced to blocks", func(t *testing.T) {
+		defs := s.Definitions()
+
+		assert.Contains(t, definitionNames(defs), "station")
+		assert.Contains(t, definitionNames(defs), "station.st12")
+		assert.Contains(t, definitionNames(defs), "station.st13.channel")
+		assert.Contains(t, definitionNames(defs), "station.st14.name")
+		assert.NotContains(t, definitionNames(defs), "station.st12.unit")
+
+		for _, def := range defs {
+			assert.Equal(t, "station", def.Registration.BlockName)
+		}
+
+		assert.Equal(t, hclFile, defs[0].Range.Filename)
+		assert.Equal(t, 1, defs[0].Range.Start.Line)
+	})
+
+	tt.Run("Definition() resolves a traversal to the most specific definition", func(t *testing.T) {
+		loc := s.At(hclFile, hcl.Pos{Line: 6, Column: 36})
+		def := s.Definition(loc.Traversal)
+
+		assert.Equal(t, "station.st13.channel", def.Name())
+		assert.Equal(t, 12, def.Range.Start.Line)
+
+		traversal, _ := hclsyntax.ParseTraversalAbs([]byte(`station["st14"].unit[0]`), "", hcl.Pos{Line: 1, Column: 1})
+		def = s.Definition(traversal)
+
+		assert.Equal(t, "station.st14", def.Name())
+		assert.Equal(t, jsonFile, def.Range.Filename)
+
+		traversal, _ = hclsyntax.ParseTraversalAbs([]byte(`unknown.value`), "", hcl.Pos{Line: 1, Column: 1})
+		assert.Nil(t, s.Definition(traversal))
+	})
+
+	tt.Run("DefinitionAt() resolves references and definitions at a position", func(t *testing.T) {
+		assert.Equal(t, "station.st13.channel", s.DefinitionAt(hclFile, hcl.Pos{Line: 6, Column: 42}).Name())
+		assert.Equal(t, "station.st13", s.DefinitionAt(hclFile, hcl.Pos{Line: 10, Column: 1}).Name())
+		assert.Equal(t, "station.st12.name", s.DefinitionAt(hclFile, hcl.Pos{Line: 2, Column: 5}).Name())
+		assert.Nil(t, s.DefinitionAt("./testdata/stations/missing.hcl", hcl.Pos{Line: 1, Column: 1}))
+	})
+
+	tt.Run("References() lists the traversals referring to a definition", func(t *testing.T) {
+		refs := s.References(s.DefinitionAt(hclFile, hcl.Pos{Line: 10, Column: 1}))
+
+		assert.Len(t, refs, 1)
+		assert.Equal(t, hclFile, refs[0].SourceRange().Filename)
+		assert.Equal(t, 6, refs[0].SourceRange().Start.Line)
+
+		assert.Empty(t, s.References(s.DefinitionAt(hclFile, hcl.Pos{Line: 2, Column: 5})))
+		assert.Empty(t, s.References(nil))
+	})
+
+	tt.Run("Definitions() traces values injected with marks", func(t *testing.T) {
+		s := spec.NewSubset(&sensitiveStationSchema{})
+		assert.False(t, s.ParseHCL([]byte("station \"st12\" {\n  name = \"Station 12\"\n}\n"), "stations.hcl").HasErrors())
+		assert.False(t, s.Parse(&hcl.EvalContext{}).HasErrors())
+
+		assert.Equal(t, []string{"station", "station.st12", "station.st12.name"}, definitionNames(s.Definitions()))
+	})
+}
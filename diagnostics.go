@@ -7,7 +7,10 @@
 package spec
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"sort"
 
 	"github.com/hashicorp/hcl/v2"
 )
@@ -15,9 +18,13 @@ import (
 // Diagnostics is used to represent a number of diagnostics returned from various places
 // in Spec parsing and file reading. Diagnostics is general purpose in nature and is meant
 // to be displayed to the end-user and read by machine.
+//
+// Suppressed contains the diagnostics silenced by suppression comments. These are never
+// considered by HasErrors or written as text but are included in machine output.
 type Diagnostics struct {
-	Spec  *Spec
-	Diags hcl.Diagnostics
+	Spec       *Spec
+	Diags      hcl.Diagnostics
+	Suppressed []*SuppressedDiagnostic
 }
 
 // newDiagnostics creaes a new Diagnostics instance.
@@ -28,6 +35,30 @@ func newDiagnostics(spec *Spec, diags hcl.Diagnostics) *Diagnostics {
 	}
 }
 
+// diagnostics creates a new Diagnostics instance for diagnostics returned from the Spec,
+// silencing suppressed diagnostics and applying the Spec's Policy when one is set.
+func (s *Spec) diagnostics(diags hcl.Diagnostics) *Diagnostics {
+	diags, suppressed := s.suppress(diags)
+
+	if s.policy != nil {
+		diags = s.policy.Apply(diags)
+	}
+
+	res := newDiagnostics(s, diags)
+	res.Suppressed = suppressed
+
+	return res
+}
+
+// derive creates a new Diagnostics from the receiver with the given diagnostics, carrying
+// over the Spec and suppressed diagnostics.
+func (d *Diagnostics) derive(diags hcl.Diagnostics) *Diagnostics {
+	res := newDiagnostics(d.Spec, diags)
+	res.Suppressed = d.Suppressed
+
+	return res
+}
+
 // Raw returns the raw hcl.Diagnostics instance. This is useful if you need to interact
 // with the raw implementation rather than the sugared version we provide. In most cases
 // you won't need this.
@@ -57,9 +88,227 @@ func (d *Diagnostics) Errs() []error {
 // when the caller is an application.
 //
 // Setting the width to 0 disables word wrapping. Setting the color to false will disable coloring of
-// key information in the output. The output will contain relevant context such as line numbers and code
-// snippets.
+// key information in the output. The output will contain relevant context such as line numbers, code
+// snippets and the values of the variables referenced by the expression that caused the diagnostic.
 func (d *Diagnostics) WriteText(to io.Writer, width uint, color bool) error {
 	wr := hcl.NewDiagnosticTextWriter(to, d.Spec.files, width, color)
-	return wr.WriteDiagnostics(d.Diags)
+
+	for _, diag := range d.Diags {
+		// the referenced variables are written by us rather than the hcl writer so sensitive
+		// values can be redacted and type information included.
+		cp := *diag
+		cp.Expression = nil
+		cp.EvalContext = nil
+
+		if err := wr.WriteDiagnostic(&cp); err != nil {
+			return err
+		}
+
+		if err := d.writeTextVariables(to, diag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Diagnostics) writeTextVariables(to io.Writer, diag *hcl.Diagnostic) error {
+	vars := d.Variables(diag)
+	if len(vars) == 0 {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("  with variables:\n")
+
+	for _, v := range vars {
+		fmt.Fprintf(buf, "    %s\n", v)
+	}
+
+	buf.WriteString("\n")
+
+	_, err := to.Write(buf.Bytes())
+	return err
+}
+
+// Len returns the number of diagnostics.
+func (d *Diagnostics) Len() int {
+	return len(d.Diags)
+}
+
+// Filter returns a new Diagnostics containing only the diagnostics for which keep returns
+// true. The diagnostics themselves are not copied.
+func (d *Diagnostics) Filter(keep func(diag *hcl.Diagnostic) bool) *Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	for _, diag := range d.Diags {
+		if keep(diag) {
+			diags = append(diags, diag)
+		}
+	}
+
+	return d.derive(diags)
+}
+
+// WithSeverity returns a new Diagnostics containing only the diagnostics of the given severity.
+func (d *Diagnostics) WithSeverity(severity hcl.DiagnosticSeverity) *Diagnostics {
+	return d.Filter(func(diag *hcl.Diagnostic) bool {
+		return diag.Severity == severity
+	})
+}
+
+// Errors returns a new Diagnostics containing only the error diagnostics.
+func (d *Diagnostics) Errors() *Diagnostics {
+	return d.WithSeverity(hcl.DiagError)
+}
+
+// Warnings returns a new Diagnostics containing only the warning diagnostics.
+func (d *Diagnostics) Warnings() *Diagnostics {
+	return d.WithSeverity(hcl.DiagWarning)
+}
+
+// InFile returns a new Diagnostics containing only the diagnostics whose subject is within
+// the given file. Diagnostics without a subject are never included.
+func (d *Diagnostics) InFile(filename string) *Diagnostics {
+	return d.Filter(func(diag *hcl.Diagnostic) bool {
+		return diag.Subject != nil && diag.Subject.Filename == filename
+	})
+}
+
+// WithCode returns a new Diagnostics containing only the diagnostics associated with one
+// of the given codes.
+func (d *Diagnostics) WithCode(codes ...Code) *Diagnostics {
+	return d.Filter(func(diag *hcl.Diagnostic) bool {
+		code := CodeOf(diag)
+
+		for _, c := range codes {
+			if code != "" && code == c {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Sort returns a new Diagnostics sorted by filename and then by the position of the subject
+// within the file. Diagnostics without a subject are placed first and diagnostics at the same
+// position are ordered with errors before warnings. The receiver is not modified.
+func (d *Diagnostics) Sort() *Diagnostics {
+	diags := make(hcl.Diagnostics, len(d.Diags))
+	copy(diags, d.Diags)
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diagnosticLess(diags[i], diags[j])
+	})
+
+	return d.derive(diags)
+}
+
+// Dedupe returns a new Diagnostics with duplicate diagnostics removed, keeping the first
+// occurrence of each. Diagnostics are duplicates when their severity, summary, detail and
+// subject are identical, which is common when the same problem is reported for several
+// merged files.
+func (d *Diagnostics) Dedupe() *Diagnostics {
+	seen := map[diagnosticKey]bool{}
+
+	return d.Filter(func(diag *hcl.Diagnostic) bool {
+		key := newDiagnosticKey(diag)
+		if seen[key] {
+			return false
+		}
+
+		seen[key] = true
+		return true
+	})
+}
+
+// GroupByFile returns the diagnostics grouped by the filename of their subject. Diagnostics
+// without a subject are grouped under an empty filename.
+func (d *Diagnostics) GroupByFile() map[string]*Diagnostics {
+	groups := map[string]*Diagnostics{}
+
+	for _, diag := range d.Diags {
+		filename := ""
+		if diag.Subject != nil {
+			filename = diag.Subject.Filename
+		}
+
+		if _, ok := groups[filename]; !ok {
+			groups[filename] = d.derive(hcl.Diagnostics{})
+		}
+
+		groups[filename].Diags = append(groups[filename].Diags, diag)
+	}
+
+	return groups
+}
+
+// Merge returns a new Diagnostics containing the diagnostics of the receiver followed by
+// the diagnostics of each of the others, in order. The Spec of the receiver is used for the
+// result so source code for all diagnostics should be available from it when writing text.
+func (d *Diagnostics) Merge(others ...*Diagnostics) *Diagnostics {
+	diags := hcl.Diagnostics{}
+	diags = diags.Extend(d.Diags)
+	suppressed := append([]*SuppressedDiagnostic{}, d.Suppressed...)
+
+	for _, other := range others {
+		if other != nil {
+			diags = diags.Extend(other.Diags)
+			suppressed = append(suppressed, other.Suppressed...)
+		}
+	}
+
+	res := newDiagnostics(d.Spec, diags)
+	res.Suppressed = suppressed
+
+	return res
+}
+
+// diagnosticKey identifies a diagnostic by its contents rather than its pointer.
+type diagnosticKey struct {
+	severity hcl.DiagnosticSeverity
+	summary  string
+	detail   string
+	subject  hcl.Range
+}
+
+func newDiagnosticKey(diag *hcl.Diagnostic) diagnosticKey {
+	key := diagnosticKey{
+		severity: diag.Severity,
+		summary:  diag.Summary,
+		detail:   diag.Detail,
+	}
+
+	if diag.Subject != nil {
+		key.subject = *diag.Subject
+	}
+
+	return key
+}
+
+// diagnosticLess reports whether a should be ordered before b.
+func diagnosticLess(a, b *hcl.Diagnostic) bool {
+	switch {
+	case a.Subject == nil && b.Subject == nil:
+		return a.Severity < b.Severity
+	case a.Subject == nil:
+		return true
+	case b.Subject == nil:
+		return false
+	}
+
+	if a.Subject.Filename != b.Subject.Filename {
+		return a.Subject.Filename < b.Subject.Filename
+	}
+
+	if a.Subject.Start.Line != b.Subject.Start.Line {
+		return a.Subject.Start.Line < b.Subject.Start.Line
+	}
+
+	if a.Subject.Start.Column != b.Subject.Start.Column {
+		return a.Subject.Start.Column < b.Subject.Start.Column
+	}
+
+	return a.Severity < b.Severity
 }
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hashicorp/hcl/v2"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// jsonOutput is the document written by WriteJSON.
+type jsonOutput struct {
+	Diagnostics []*jsonDiagnostic `json:"diagnostics"`
+	Suppressed  []*jsonDiagnostic `json:"suppressed"`
+}
+
+type jsonDiagnostic struct {
+	Severity    string           `json:"severity"`
+	Code        Code             `json:"code,omitempty"`
+	Summary     string           `json:"summary"`
+	Detail      string           `json:"detail,omitempty"`
+	Range       *jsonRange       `json:"range,omitempty"`
+	Variables   []*jsonVariable  `json:"variables,omitempty"`
+	Suppression *jsonSuppression `json:"suppression,omitempty"`
+}
+
+type jsonVariable struct {
+	Name      string          `json:"name"`
+	Type      json.RawMessage `json:"type"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+type jsonSuppression struct {
+	Reason string     `json:"reason,omitempty"`
+	Range  *jsonRange `json:"range"`
+}
+
+type jsonRange struct {
+	Filename string  `json:"filename"`
+	Start    jsonPos `json:"start"`
+	End      jsonPos `json:"end"`
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// WriteJSON writes the diagnostics as a JSON document to the provided io.Writer. This is useful
+// when the caller is an application or other tooling rather than an end-user. The document lists
+// the diagnostics along with any diagnostics silenced by suppression comments.
+func (d *Diagnostics) WriteJSON(to io.Writer) error {
+	out := &jsonOutput{
+		Diagnostics: make([]*jsonDiagnostic, 0, len(d.Diags)),
+		Suppressed:  make([]*jsonDiagnostic, 0, len(d.Suppressed)),
+	}
+
+	for _, diag := range d.Diags {
+		out.Diagnostics = append(out.Diagnostics, d.jsonDiagnostic(diag))
+	}
+
+	for _, sup := range d.Suppressed {
+		diag := d.jsonDiagnostic(sup.Diagnostic)
+		diag.Suppression = &jsonSuppression{
+			Reason: sup.Suppression.Reason,
+			Range:  newJSONRange(&sup.Suppression.Range),
+		}
+
+		out.Suppressed = append(out.Suppressed, diag)
+	}
+
+	enc := json.NewEncoder(to)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+}
+
+func (d *Diagnostics) jsonDiagnostic(diag *hcl.Diagnostic) *jsonDiagnostic {
+	res := &jsonDiagnostic{
+		Severity: severityString(diag.Severity),
+		Code:     CodeOf(diag),
+		Summary:  diag.Summary,
+		Detail:   diag.Detail,
+		Range:    newJSONRange(diag.Subject),
+	}
+
+	for _, v := range d.Variables(diag) {
+		res.Variables = append(res.Variables, newJSONVariable(v))
+	}
+
+	return res
+}
+
+// newJSONVariable creates the JSON representation of the variable. The type is written using
+// the cty JSON type format and the value is omitted when it is sensitive or not yet known.
+func newJSONVariable(v *DiagnosticVariable) *jsonVariable {
+	res := &jsonVariable{
+		Name:      v.Name,
+		Sensitive: v.Sensitive,
+	}
+
+	if ty, err := ctyjson.MarshalType(v.Type); err == nil {
+		res.Type = ty
+	}
+
+	if !v.Sensitive && v.Value.IsWhollyKnown() {
+		if val, err := ctyjson.Marshal(v.Value, v.Type); err == nil {
+			res.Value = val
+		}
+	}
+
+	return res
+}
+
+func newJSONRange(rng *hcl.Range) *jsonRange {
+	if rng == nil {
+		return nil
+	}
+
+	return &jsonRange{
+		Filename: rng.Filename,
+		Start:    jsonPos{Line: rng.Start.Line, Column: rng.Start.Column, Byte: rng.Start.Byte},
+		End:      jsonPos{Line: rng.End.Line, Column: rng.End.Column, Byte: rng.End.Byte},
+	}
+}
+
+// severityString returns the lowercase name of the severity.
+func severityString(severity hcl.DiagnosticSeverity) string {
+	switch severity {
+	case hcl.DiagError:
+		return "error"
+	case hcl.DiagWarning:
+		return "warning"
+	default:
+		return "invalid"
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSON(tt *testing.T) {
+	tt.Run("WriteJSON() writes diagnostics and suppressed diagnostics", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("# spec:ignore SPEC012 set later\nunit {\n}\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{}).Merge(s.Files("unit.txt"))
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteJSON(b))
+
+		out := struct {
+			Diagnostics []map[string]interface{} `json:"diagnostics"`
+			Suppressed  []map[string]interface{} `json:"suppressed"`
+		}{}
+		assert.NoError(t, json.Unmarshal(b.Bytes(), &out))
+
+		assert.Len(t, out.Diagnostics, 1)
+		assert.Equal(t, "error", out.Diagnostics[0]["severity"])
+		assert.Equal(t, string(spec.CodeCannotDetermineFileType), out.Diagnostics[0]["code"])
+
+		assert.Len(t, out.Suppressed, 1)
+		assert.Equal(t, string(spec.CodeMissingRequiredArgument), out.Suppressed[0]["code"])
+		assert.Equal(t, "set later", out.Suppressed[0]["suppression"].(map[string]interface{})["reason"])
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// SARIF document constants
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string       `json:"name"`
+	Rules []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID       string              `json:"ruleId,omitempty"`
+	Level        string              `json:"level"`
+	Message      sarifMessage        `json:"message"`
+	Locations    []*sarifLocation    `json:"locations,omitempty"`
+	Suppressions []*sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// WriteSARIF writes the diagnostics as a SARIF 2.1.0 log to the provided io.Writer, reported as
+// produced by the tool with the given name. This allows the diagnostics to be shown by code
+// scanning services. Each Code is reported as a rule and diagnostics silenced by suppression
+// comments are included with an in-source suppression.
+func (d *Diagnostics) WriteSARIF(to io.Writer, toolName string) error {
+	run := &sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: toolName, Rules: []*sarifRule{}}},
+		Results: make([]*sarifResult, 0, len(d.Diags)+len(d.Suppressed)),
+	}
+
+	rules := map[Code]*sarifRule{}
+
+	add := func(diag *hcl.Diagnostic) *sarifResult {
+		code := CodeOf(diag)
+		if code != "" && rules[code] == nil {
+			rules[code] = &sarifRule{ID: string(code), ShortDescription: sarifMessage{Text: diag.Summary}}
+		}
+
+		res := newSARIFResult(diag)
+		run.Results = append(run.Results, res)
+
+		return res
+	}
+
+	for _, diag := range d.Diags {
+		add(diag)
+	}
+
+	for _, sup := range d.Suppressed {
+		res := add(sup.Diagnostic)
+		res.Suppressions = []*sarifSuppression{
+			{Kind: "inSource", Justification: sup.Suppression.Reason},
+		}
+	}
+
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	enc := json.NewEncoder(to)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(&sarifLog{
+		Version: SARIFVersion,
+		Schema:  SARIFSchema,
+		Runs:    []*sarifRun{run},
+	})
+}
+
+func newSARIFResult(diag *hcl.Diagnostic) *sarifResult {
+	res := &sarifResult{
+		RuleID:  string(CodeOf(diag)),
+		Level:   sarifLevel(diag.Severity),
+		Message: sarifMessage{Text: diag.Summary},
+	}
+
+	if diag.Detail != "" {
+		res.Message.Text = diag.Summary + ": " + diag.Detail
+	}
+
+	if diag.Subject != nil {
+		res.Locations = []*sarifLocation{
+			{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(diag.Subject.Filename)},
+					Region: &sarifRegion{
+						StartLine:   diag.Subject.Start.Line,
+						StartColumn: diag.Subject.Start.Column,
+						EndLine:     diag.Subject.End.Line,
+						EndColumn:   diag.Subject.End.Column,
+					},
+				},
+			},
+		}
+	}
+
+	return res
+}
+
+// sarifLevel returns the SARIF level of the severity. Severities without a level, such as
+// hcl.DiagInvalid, are reported as "none".
+func sarifLevel(severity hcl.DiagnosticSeverity) string {
+	switch severity {
+	case hcl.DiagError:
+		return "error"
+	case hcl.DiagWarning:
+		return "warning"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSARIF(tt *testing.T) {
+	tt.Run("WriteSARIF() writes results, rules and suppressions", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("# spec:ignore SPEC012 set later\nunit {\n}\n"), "unit.hcl")
+		s.ParseHCL([]byte("unit {\n}\n"), "units.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{}).Merge(s.Files("unit.txt"))
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteSARIF(b, "spec"))
+
+		out := struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Name  string `json:"name"`
+						Rules []struct {
+							ID string `json:"id"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []struct {
+					RuleID    string `json:"ruleId"`
+					Level     string `json:"level"`
+					Locations []struct {
+						PhysicalLocation struct {
+							ArtifactLocation struct {
+								URI string `json:"uri"`
+							} `json:"artifactLocation"`
+							Region struct {
+								StartLine int `json:"startLine"`
+							} `json:"region"`
+						} `json:"physicalLocation"`
+					} `json:"locations"`
+					Suppressions []struct {
+						Kind          string `json:"kind"`
+						Justification string `json:"justification"`
+					} `json:"suppressions"`
+				} `json:"results"`
+			} `json:"runs"`
+		}{}
+		assert.NoError(t, json.Unmarshal(b.Bytes(), &out))
+
+		assert.Equal(t, spec.SARIFVersion, out.Version)
+		assert.Len(t, out.Runs, 1)
+
+		run := out.Runs[0]
+		assert.Equal(t, "spec", run.Tool.Driver.Name)
+		assert.Len(t, run.Tool.Driver.Rules, 2)
+		assert.Equal(t, string(spec.CodeCannotDetermineFileType), run.Tool.Driver.Rules[0].ID)
+		assert.Equal(t, string(spec.CodeMissingRequiredArgument), run.Tool.Driver.Rules[1].ID)
+
+		assert.Len(t, run.Results, 3)
+		assert.Equal(t, "error", run.Results[0].Level)
+		assert.Equal(t, "units.hcl", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, 1, run.Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+		assert.Empty(t, run.Results[1].Locations)
+		assert.Equal(t, "inSource", run.Results[2].Suppressions[0].Kind)
+		assert.Equal(t, "set later", run.Results[2].Suppressions[0].Justification)
+	})
+
+	tt.Run("WriteSARIF() reports severities without a SARIF level as none", func(t *testing.T) {
+		diags := &spec.Diagnostics{Diags: hcl.Diagnostics{{Severity: hcl.DiagInvalid, Summary: "Unknown"}}}
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteSARIF(b, "spec"))
+		assert.Contains(t, b.String(), `"level": "none"`)
+		assert.NotContains(t, b.String(), `"invalid"`)
+	})
+}
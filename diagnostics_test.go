@@ -58,3 +58,81 @@ func TestDiagnostics(tt *testing.T) {
 		assert.Contains(t, b.String(), "This is a fake error.")
 	})
 }
+
+func TestDiagnosticsQueries(tt *testing.T) {
+	diags := &spec.Diagnostics{
+		Spec: spec.NewSubset(),
+		Diags: hcl.Diagnostics{
+			{
+				Severity: hcl.DiagWarning,
+				Summary:  "Second warning.",
+				Subject:  &hcl.Range{Filename: "b.hcl", Start: hcl.Pos{Line: 1, Column: 1}},
+			},
+			{
+				Severity: hcl.DiagError,
+				Summary:  spec.DiagCannotDetermineFileType,
+			},
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Subject:  &hcl.Range{Filename: "a.hcl", Start: hcl.Pos{Line: 4, Column: 1}},
+			},
+			{
+				Severity: hcl.DiagWarning,
+				Summary:  "First warning.",
+				Subject:  &hcl.Range{Filename: "a.hcl", Start: hcl.Pos{Line: 2, Column: 3}},
+			},
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Subject:  &hcl.Range{Filename: "a.hcl", Start: hcl.Pos{Line: 4, Column: 1}},
+			},
+		},
+	}
+
+	tt.Run("Errors() and Warnings() filter by severity", func(t *testing.T) {
+		assert.Equal(t, 3, diags.Errors().Len())
+		assert.Equal(t, 2, diags.Warnings().Len())
+		assert.False(t, diags.Warnings().HasErrors())
+	})
+
+	tt.Run("InFile() only includes diagnostics with a subject in the file", func(t *testing.T) {
+		assert.Equal(t, 3, diags.InFile("a.hcl").Len())
+		assert.Equal(t, 0, diags.InFile("c.hcl").Len())
+	})
+
+	tt.Run("WithCode() filters by the diagnostic code", func(t *testing.T) {
+		assert.Equal(t, 2, diags.WithCode(spec.CodeMissingRequiredArgument).Len())
+		assert.Equal(t, 3, diags.WithCode(spec.CodeMissingRequiredArgument, spec.CodeCannotDetermineFileType).Len())
+	})
+
+	tt.Run("Sort() orders by file and position without modifying the receiver", func(t *testing.T) {
+		sorted := diags.Sort().Raw()
+
+		assert.Equal(t, spec.DiagCannotDetermineFileType, sorted[0].Summary)
+		assert.Equal(t, "First warning.", sorted[1].Summary)
+		assert.Equal(t, "Missing required argument", sorted[2].Summary)
+		assert.Equal(t, "Second warning.", sorted[4].Summary)
+		assert.Equal(t, "Second warning.", diags.Raw()[0].Summary)
+	})
+
+	tt.Run("Dedupe() removes identical diagnostics", func(t *testing.T) {
+		assert.Equal(t, 4, diags.Dedupe().Len())
+	})
+
+	tt.Run("GroupByFile() groups diagnostics by their subject filename", func(t *testing.T) {
+		groups := diags.GroupByFile()
+
+		assert.Len(t, groups, 3)
+		assert.Equal(t, 3, groups["a.hcl"].Len())
+		assert.Equal(t, 1, groups["b.hcl"].Len())
+		assert.Equal(t, 1, groups[""].Len())
+	})
+
+	tt.Run("Merge() combines diagnostics in order", func(t *testing.T) {
+		merged := diags.Errors().Merge(diags.Warnings(), nil)
+
+		assert.Equal(t, 5, merged.Len())
+		assert.Equal(t, hcl.DiagWarning, merged.Raw()[4].Severity)
+	})
+}
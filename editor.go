@@ -0,0 +1,370 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// diagnostic messages
+const (
+	DiagCannotEdit             = "Cannot edit"
+	DiagCannotEditNotHCL       = "The file %q has not been parsed as HCL. Only HCL files can be edited."
+	DiagCannotEditUnknown      = "The path %q is not described by the spec."
+	DiagCannotEditLabels       = "The %q block requires %d label(s)."
+	DiagCannotEditNotFound     = "The path %q does not exist in the file."
+	DiagCannotEditAmbiguous    = "The path %q matches more than one block. Blocks can only be edited when the path identifies a single block."
+	DiagCannotEditNotAttribute = "The path %q is not an attribute."
+	DiagCannotEditNotBlock     = "The path %q is not a block."
+	DiagCannotEditExists       = "The block %q already exists and cannot be repeated."
+	DiagCannotEditWrite        = "The file %q could not be written: %s"
+)
+
+// Editor changes the attributes and blocks of a single parsed HCL file using hclwrite, keeping
+// the comments and formatting of everything it does not change. Changes are made in memory
+// until Save is called.
+//
+// Paths contain the types and labels of the enclosing blocks followed by the name of the
+// attribute or block, in the same form as Location.Path. For example the path
+// ["station", "st12", "name"] refers to the name attribute of the station block labeled "st12".
+// The number of labels of each block is taken from the registered BlockDefinition's so only
+// blocks and attributes described by the Spec can be edited.
+type Editor struct {
+	spec     *Spec
+	filename string
+	file     *hclwrite.File
+	schema   *parser.SchemaBody
+}
+
+// editTarget is the attribute or block a path refers to along with the body containing it.
+type editTarget struct {
+	path   string
+	body   *hclwrite.Body
+	name   string
+	labels []string
+
+	// block is the schema of the target block or nil when the target is an attribute.
+	block *parser.SchemaBlock
+}
+
+// Edit opens the parsed HCL file for editing. Error diagnostics are returned when the file has
+// not been parsed or was parsed as JSON.
+func (s *Spec) Edit(filename string) (*Editor, *Diagnostics) {
+	file, ok := s.files[filename]
+	if !ok || !isHCLBody(file.Body) {
+		return nil, s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotEdit,
+				Detail:   fmt.Sprintf(DiagCannotEditNotHCL, filename),
+			},
+		})
+	}
+
+	wfile, diags := hclwrite.ParseConfig(file.Bytes, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagFileNotEditable,
+				Detail:   DiagFileNotEditableDetail,
+				Subject:  &hcl.Range{Filename: filename},
+			},
+		})
+	}
+
+	return &Editor{
+		spec:     s,
+		filename: filename,
+		file:     wfile,
+		schema:   parser.Introspect(s.Build()),
+	}, s.diagnostics(nil)
+}
+
+// Filename returns the name of the file being edited.
+func (e *Editor) Filename() string {
+	return e.filename
+}
+
+// SetAttribute sets the attribute at the path to the value, replacing the expression of an
+// existing attribute or adding the attribute to the end of its block. The enclosing blocks must
+// already exist.
+func (e *Editor) SetAttribute(path []string, val cty.Value) *Diagnostics {
+	target, diags := e.target(path)
+	if !diags.HasErrors() && target.block != nil {
+		diags = e.cannotEdit(DiagCannotEditNotAttribute, target.path)
+	}
+
+	if diags.HasErrors() {
+		return e.spec.diagnostics(diags)
+	}
+
+	target.body.SetAttributeValue(target.name, val)
+
+	return e.spec.diagnostics(nil)
+}
+
+// RemoveAttribute removes the attribute at the path.
+func (e *Editor) RemoveAttribute(path []string) *Diagnostics {
+	target, diags := e.target(path)
+	if !diags.HasErrors() && target.block != nil {
+		diags = e.cannotEdit(DiagCannotEditNotAttribute, target.path)
+	}
+
+	if !diags.HasErrors() && target.body.GetAttribute(target.name) == nil {
+		diags = e.cannotEdit(DiagCannotEditNotFound, target.path)
+	}
+
+	if diags.HasErrors() {
+		return e.spec.diagnostics(diags)
+	}
+
+	target.body.RemoveAttribute(target.name)
+
+	return e.spec.diagnostics(nil)
+}
+
+// AddBlock adds an empty block to the end of its enclosing block, or of the file, where the path
+// ends with the type of the block followed by its labels. Blocks that cannot be repeated, and
+// labeled blocks with the same labels as an existing block, cannot be added twice.
+func (e *Editor) AddBlock(path []string) *Diagnostics {
+	target, diags := e.target(path)
+	if !diags.HasErrors() && target.block == nil {
+		diags = e.cannotEdit(DiagCannotEditNotBlock, target.path)
+	}
+
+	if diags.HasErrors() {
+		return e.spec.diagnostics(diags)
+	}
+
+	switch target.block.Nesting {
+	case parser.NestingSingle, parser.NestingAttrs, parser.NestingMap, parser.NestingObject:
+		if len(findEditBlocks(target.body, target.name, target.labels)) > 0 {
+			return e.spec.diagnostics(e.cannotEdit(DiagCannotEditExists, target.path))
+		}
+	}
+
+	appendBlock(target.body, target.name, target.labels)
+
+	return e.spec.diagnostics(nil)
+}
+
+// RemoveBlock removes the block at the path, where the path ends with the type of the block
+// followed by its labels, along with everything it contains and the comments directly above it.
+func (e *Editor) RemoveBlock(path []string) *Diagnostics {
+	target, diags := e.target(path)
+	if !diags.HasErrors() && target.block == nil {
+		diags = e.cannotEdit(DiagCannotEditNotBlock, target.path)
+	}
+
+	if diags.HasErrors() {
+		return e.spec.diagnostics(diags)
+	}
+
+	blocks := findEditBlocks(target.body, target.name, target.labels)
+
+	switch len(blocks) {
+	case 0:
+		return e.spec.diagnostics(e.cannotEdit(DiagCannotEditNotFound, target.path))
+	case 1:
+		target.body.RemoveBlock(blocks[0])
+		return e.spec.diagnostics(nil)
+	default:
+		return e.spec.diagnostics(e.cannotEdit(DiagCannotEditAmbiguous, target.path))
+	}
+}
+
+// Bytes returns the edited contents of the file.
+func (e *Editor) Bytes() []byte {
+	return e.file.Bytes()
+}
+
+// Validate parses the edited contents of the file together with the other files of the Spec
+// and returns the resulting diagnostics. The edits are validated against a copy of the Spec so
+// the files and values of the Spec are not changed.
+func (e *Editor) Validate(ctx *hcl.EvalContext) *Diagnostics {
+	s := e.spec.clone()
+	diags := s.ParseHCL(e.Bytes(), e.filename)
+
+	return diags.Merge(s.Parse(ctx))
+}
+
+// ChangeSet returns the edited file as a ChangeSet, which is empty when nothing has changed.
+func (e *Editor) ChangeSet() *ChangeSet {
+	changes := newChangeSet()
+
+	if src := e.Bytes(); !bytes.Equal(src, e.spec.files[e.filename].Bytes) {
+		changes.files[e.filename] = src
+	}
+
+	return changes
+}
+
+// Save validates the edited file and, when valid, writes it in place and replaces the file
+// within the Spec. The file is not written when Validate returns errors. Parse must be called
+// again for the values of the Spec to reflect the edits.
+func (e *Editor) Save(ctx *hcl.EvalContext) *Diagnostics {
+	diags := e.Validate(ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	changes := e.ChangeSet()
+	if changes.Len() == 0 {
+		return diags
+	}
+
+	if err := changes.Apply(); err != nil {
+		return diags.Merge(e.spec.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotEdit,
+				Detail:   fmt.Sprintf(DiagCannotEditWrite, e.filename, err),
+			},
+		}))
+	}
+
+	e.spec.parseHCL(changes.Bytes(e.filename), e.filename)
+
+	return diags
+}
+
+// target resolves the path against the schema and the edited file. The enclosing blocks of the
+// target must exist while the target itself may not.
+func (e *Editor) target(path []string) (*editTarget, hcl.Diagnostics) {
+	display := strings.Join(path, ".")
+	if len(path) == 0 {
+		return nil, e.cannotEdit(DiagCannotEditUnknown, display)
+	}
+
+	body := e.file.Body()
+	schema := e.schema
+
+	// attrs is the enclosing block when it accepts arbitrary attributes
+	var attrs *parser.SchemaBlock
+
+	for i := 0; i < len(path); {
+		name := path[i]
+		i++
+
+		if attrs != nil {
+			if i < len(path) || !hclsyntax.ValidIdentifier(name) {
+				return nil, e.cannotEdit(DiagCannotEditUnknown, display)
+			}
+
+			return &editTarget{path: display, body: body, name: name}, nil
+		}
+
+		if _, ok := schema.Attributes[name]; ok && i == len(path) {
+			return &editTarget{path: display, body: body, name: name}, nil
+		}
+
+		block, ok := schema.Blocks[name]
+		if !ok {
+			return nil, e.cannotEdit(DiagCannotEditUnknown, display)
+		}
+
+		if i+len(block.LabelNames) > len(path) {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  DiagCannotEdit,
+					Detail:   fmt.Sprintf(DiagCannotEditLabels, name, len(block.LabelNames)),
+				},
+			}
+		}
+
+		labels := path[i : i+len(block.LabelNames)]
+		i += len(block.LabelNames)
+
+		if i == len(path) {
+			return &editTarget{path: display, body: body, name: name, labels: labels, block: block}, nil
+		}
+
+		blocks := findEditBlocks(body, name, labels)
+		switch len(blocks) {
+		case 0:
+			return nil, e.cannotEdit(DiagCannotEditNotFound, strings.Join(path[:i], "."))
+		case 1:
+		default:
+			return nil, e.cannotEdit(DiagCannotEditAmbiguous, strings.Join(path[:i], "."))
+		}
+
+		body = blocks[0].Body()
+		schema = block.Body
+
+		if block.Nesting == parser.NestingAttrs {
+			attrs = block
+		}
+	}
+
+	return nil, e.cannotEdit(DiagCannotEditUnknown, display)
+}
+
+func (e *Editor) cannotEdit(detail, path string) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagCannotEdit,
+			Detail:   fmt.Sprintf(detail, path),
+			Subject:  &hcl.Range{Filename: e.filename},
+		},
+	}
+}
+
+// findEditBlocks returns the blocks of the body with the type and labels.
+func findEditBlocks(body *hclwrite.Body, typeName string, labels []string) []*hclwrite.Block {
+	res := []*hclwrite.Block{}
+
+	for _, block := range body.Blocks() {
+		if block.Type() == typeName && equalStrings(block.Labels(), labels) {
+			res = append(res, block)
+		}
+	}
+
+	return res
+}
+
+// clone returns a copy of the Spec with its own registrar, files and suppressions so that
+// parsing the copy does not change the receiver.
+func (s *Spec) clone() *Spec {
+	registrar := parser.NewRegistrar(s.registrar.IncreaseNextOrderBy)
+	registrar.NextOrder = s.registrar.NextOrder
+	registrar.Strict = s.registrar.Strict
+
+	for _, reg := range s.registrar.Registrations() {
+		registrar.AddRegistration(reg)
+	}
+
+	res := &Spec{
+		registrar:    registrar,
+		files:        specFiles{},
+		suppressions: map[string][]*Suppression{},
+		policy:       s.policy,
+		sensitive:    s.sensitive,
+		migrations:   s.migrations,
+		validations:  s.validations,
+	}
+
+	for filename, file := range s.files {
+		res.files[filename] = file
+	}
+
+	for filename, suppressions := range s.suppressions {
+		res.suppressions[filename] = append([]*Suppression{}, suppressions...)
+	}
+
+	return res
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const editSource = `# Stations managed by hand.
+station "st12" {
+  name    = "Station 12" # the main station
+  channel = 12
+
+  unit {
+    callsign = "M12"
+  }
+
+  unit {
+    callsign = "E12"
+  }
+}
+`
+
+func TestEditor(tt *testing.T) {
+	newEditor := func(t *testing.T) (*spec.Spec, *spec.Editor) {
+		s := spec.NewSubset(&stationSchema{})
+		assert.False(t, s.ParseHCL([]byte(editSource), "stations.hcl").HasErrors())
+
+		e, diags := s.Edit("stations.hcl")
+		assert.False(t, diags.HasErrors())
+
+		return s, e
+	}
+
+	tt.Run("Edit() requires a parsed HCL file", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		assert.False(t, s.Files("./testdata/stations/stations.json").HasErrors())
+
+		for _, filename := range []string{"./testdata/stations/stations.json", "missing.hcl"} {
+			e, diags := s.Edit(filename)
+			assert.Nil(t, e)
+			assert.Equal(t, spec.CodeCannotEdit, spec.CodeOf(diags.Raw()[0]))
+		}
+	})
+
+	tt.Run("SetAttribute() keeps comments and formatting", func(t *testing.T) {
+		_, e := newEditor(t)
+
+		assert.False(t, e.SetAttribute([]string{"station", "st12", "channel"}, cty.NumberIntVal(14)).HasErrors())
+
+		src := string(e.Bytes())
+		assert.Contains(t, src, "# Stations managed by hand.\n")
+		assert.Contains(t, src, `name    = "Station 12" # the main station`)
+		assert.Contains(t, src, "channel = 14\n")
+	})
+
+	tt.Run("SetAttribute() adds missing attributes", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		assert.False(t, s.ParseHCL([]byte("station \"st12\" {\n  name = \"Station 12\"\n}\n"), "stations.hcl").HasErrors())
+
+		e, _ := s.Edit("stations.hcl")
+		assert.False(t, e.SetAttribute([]string{"station", "st12", "channel"}, cty.NumberIntVal(12)).HasErrors())
+		assert.Equal(t, "station \"st12\" {\n  name    = \"Station 12\"\n  channel = 12\n}\n", string(e.Bytes()))
+	})
+
+	tt.Run("RemoveAttribute() removes an existing attribute", func(t *testing.T) {
+		_, e := newEditor(t)
+
+		assert.False(t, e.RemoveAttribute([]string{"station", "st12", "channel"}).HasErrors())
+		assert.NotContains(t, string(e.Bytes()), "channel")
+
+		diags := e.RemoveAttribute([]string{"station", "st12", "channel"})
+		assert.Equal(t, spec.CodeCannotEdit, spec.CodeOf(diags.Raw()[0]))
+	})
+
+	tt.Run("AddBlock() and RemoveBlock() change blocks by type and labels", func(t *testing.T) {
+		_, e := newEditor(t)
+
+		assert.False(t, e.AddBlock([]string{"station", "st13"}).HasErrors())
+		assert.False(t, e.SetAttribute([]string{"station", "st13", "name"}, cty.StringVal("Station 13")).HasErrors())
+		assert.True(t, e.AddBlock([]string{"station", "st13"}).HasErrors())
+		assert.Contains(t, string(e.Bytes()), "}\n\nstation \"st13\" {\n  name = \"Station 13\"\n}\n")
+
+		assert.False(t, e.RemoveBlock([]string{"station", "st12"}).HasErrors())
+		assert.NotContains(t, string(e.Bytes()), "st12")
+		assert.NotContains(t, string(e.Bytes()), "# Stations managed by hand.")
+	})
+
+	tt.Run("paths must be described by the spec and identify a single block", func(t *testing.T) {
+		_, e := newEditor(t)
+
+		for _, path := range [][]string{
+			{},
+			{"radio", "r1"},
+			{"station"},
+			{"station", "st12", "missing"},
+			{"station", "st99", "name"},
+			{"station", "st12", "unit", "callsign"},
+			{"station", "st12", "name", "value"},
+		} {
+			diags := e.SetAttribute(path, cty.StringVal("value"))
+			assert.Equal(t, spec.CodeCannotEdit, spec.CodeOf(diags.Raw()[0]), path)
+		}
+
+		assert.True(t, e.RemoveBlock([]string{"station", "st12", "unit"}).HasErrors())
+		assert.True(t, e.AddBlock([]string{"station", "st12", "name"}).HasErrors())
+		assert.Equal(t, editSource, string(e.Bytes()))
+	})
+
+	tt.Run("Validate() checks the edits without changing the Spec", func(t *testing.T) {
+		s, e := newEditor(t)
+
+		assert.False(t, e.Validate(&hcl.EvalContext{}).HasErrors())
+
+		e.RemoveAttribute([]string{"station", "st12", "name"})
+
+		diags := e.Validate(&hcl.EvalContext{})
+		assert.True(t, diags.HasErrors())
+		assert.Equal(t, spec.CodeMissingRequiredArgument, spec.CodeOf(diags.Errors().Raw()[0]))
+
+		original, _ := s.Edit("stations.hcl")
+		assert.Equal(t, editSource, string(original.Bytes()))
+		assert.Equal(t, 1, e.ChangeSet().Len())
+	})
+
+	tt.Run("Save() writes valid edits in place", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "spec")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "stations.hcl")
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(editSource), 0600))
+
+		s := spec.NewSubset(&stationSchema{})
+		assert.False(t, s.Files(filename).HasErrors())
+
+		e, _ := s.Edit(filename)
+
+		e.RemoveAttribute([]string{"station", "st12", "name"})
+		assert.True(t, e.Save(&hcl.EvalContext{}).HasErrors())
+
+		src, _ := ioutil.ReadFile(filename)
+		assert.Equal(t, editSource, string(src))
+
+		e.SetAttribute([]string{"station", "st12", "name"}, cty.StringVal("Station 14"))
+		assert.False(t, e.Save(&hcl.EvalContext{}).HasErrors())
+
+		src, _ = ioutil.ReadFile(filename)
+		assert.Contains(t, string(src), "  }\n  name = \"Station 14\"\n}\n")
+
+		saved, _ := s.Edit(filename)
+		assert.Equal(t, string(src), string(saved.Bytes()))
+
+		info, err := os.Stat(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// diagnostic messages
+const (
+	DiagCannotEncode            = "Value cannot be encoded"
+	DiagCannotEncodeStruct      = "Only structs, or pointers to structs, can be encoded but the value is a %s."
+	DiagCannotEncodeObject      = "The value must be an object with a property for each registered block but is %s."
+	DiagCannotEncodeType        = "The value of %q must be %s but is %s."
+	DiagCannotEncodeUnknown     = "The value of %q is not known."
+	DiagCannotEncodeTransformed = "The value of %q is transformed by the spec and cannot be encoded."
+)
+
+// EncodedFilename is the filename used in the diagnostics of an encoded document, which is
+// parsed against the Spec before it is returned.
+const EncodedFilename = "encoded.hcl"
+
+// Encode returns a document in the given Syntax containing the Go value, which must be a struct
+// or a pointer to a struct using the same "hcl" struct tags as Decode. The value is decoded
+// against the registered BlockDefinition's and written using EncodeValue, so nil is returned
+// along with the diagnostics when it does not match them.
+func (s *Spec) Encode(val interface{}, syntax Syntax) ([]byte, *Diagnostics) {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, s.diagnostics(encodeError(fmt.Sprintf(DiagCannotEncodeStruct, rv.Kind())))
+	}
+
+	file := hclwrite.NewEmptyFile()
+	gohcl.EncodeIntoBody(val, file.Body())
+
+	check, diags := s.checkEncoded(file.Bytes())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	decoded, decodeDiags := hcldec.Decode(check.Body(), check.Build(), &hcl.EvalContext{})
+	if decodeDiags.HasErrors() {
+		return nil, diags.Merge(check.diagnostics(decodeDiags))
+	}
+
+	return s.EncodeValue(decoded, syntax)
+}
+
+// EncodeValue returns a document in the given Syntax containing the cty.Value, which must be an
+// object in the shape of the value decoded from the hcldec.Spec returned by Build, with a
+// property for each registered block. The registrations are written in the order they are
+// parsed, and within each block the attributes are written before the nested blocks, in lexical
+// order. Null values are left out.
+//
+// The document is parsed against the registered BlockDefinition's before it is returned, and nil
+// is returned along with the diagnostics when it does not match them. Values that are unknown,
+// or are transformed by the hcldec.Spec, cannot be encoded.
+func (s *Spec) EncodeValue(val cty.Value, syntax Syntax) ([]byte, *Diagnostics) {
+	if val == cty.NilVal {
+		return nil, s.diagnostics(encodeError(fmt.Sprintf(DiagCannotEncodeObject, "no value")))
+	}
+
+	if val.IsNull() || !val.Type().IsObjectType() {
+		return nil, s.diagnostics(encodeError(fmt.Sprintf(DiagCannotEncodeObject, val.Type().FriendlyName())))
+	}
+
+	file := hclwrite.NewEmptyFile()
+
+	for _, reg := range s.orderedRegistrations() {
+		if !val.Type().HasAttribute(reg.BlockName) {
+			continue
+		}
+
+		diags := encodeSpec(file.Body(), reg.Definition.Spec(), val.GetAttr(reg.BlockName), reg.BlockName)
+		if diags.HasErrors() {
+			return nil, s.diagnostics(diags)
+		}
+	}
+
+	return s.encoded(file, syntax)
+}
+
+// encoded formats the encoded file, checks it using checkEncoded and, when valid, returns it in
+// the given Syntax.
+func (s *Spec) encoded(file *hclwrite.File, syntax Syntax) ([]byte, *Diagnostics) {
+	if syntax != SyntaxHCL && syntax != SyntaxJSON {
+		return nil, s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotDetermineFileType,
+				Detail:   DiagCannotDetermineFileTypeDetail,
+			},
+		})
+	}
+
+	src := hclwrite.Format(append(s.versionHeader(), file.Bytes()...))
+
+	check, diags := s.checkEncoded(src)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if syntax == SyntaxJSON {
+		res, convDiags := convertToJSON(check.files[EncodedFilename].Body.(*hclsyntax.Body), src, EncodedFilename)
+		return res, diags.Merge(check.diagnostics(convDiags))
+	}
+
+	return src, diags
+}
+
+// checkEncoded parses the encoded document against a copy of the Spec without any other files,
+// returning the copy.
+func (s *Spec) checkEncoded(src []byte) (*Spec, *Diagnostics) {
+	check := s.clone()
+	check.files = specFiles{}
+	check.suppressions = map[string][]*Suppression{}
+
+	diags := check.ParseHCL(src, EncodedFilename)
+
+	return check, diags.Merge(check.Parse(&hcl.EvalContext{}))
+}
+
+// encodeSpec writes the value decoded by the spec into the body. The path is used to describe
+// the value in diagnostics.
+func encodeSpec(body *hclwrite.Body, spec hcldec.Spec, val cty.Value, path string) hcl.Diagnostics {
+	if val.IsNull() {
+		return nil
+	}
+
+	if !val.IsWhollyKnown() {
+		return encodeError(fmt.Sprintf(DiagCannotEncodeUnknown, path))
+	}
+
+	switch s := spec.(type) {
+	case hcldec.ObjectSpec:
+		if !val.Type().IsObjectType() {
+			return encodeTypeError(path, "an object", val)
+		}
+
+		keys := make([]string, 0, len(s))
+		for key := range s {
+			if val.Type().HasAttribute(key) {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		// write the attributes before the blocks
+		for _, blocks := range []bool{false, true} {
+			for _, key := range keys {
+				if isBlockSpec(s[key]) != blocks {
+					continue
+				}
+
+				if diags := encodeSpec(body, s[key], val.GetAttr(key), path+"."+key); diags.HasErrors() {
+					return diags
+				}
+			}
+		}
+	case hcldec.TupleSpec:
+		if !val.Type().IsTupleType() || val.LengthInt() != len(s) {
+			return encodeTypeError(path, fmt.Sprintf("a tuple of %d elements", len(s)), val)
+		}
+
+		for i, child := range s {
+			if diags := encodeSpec(body, child, val.Index(cty.NumberIntVal(int64(i))), fmt.Sprintf("%s[%d]", path, i)); diags.HasErrors() {
+				return diags
+			}
+		}
+	case *hcldec.AttrSpec:
+		body.SetAttributeValue(s.Name, val)
+	case *hcldec.DefaultSpec:
+		return encodeSpec(body, s.Primary, val, path)
+	case *hcldec.ValidateSpec:
+		return encodeSpec(body, s.Wrapped, val, path)
+	case *hcldec.TransformExprSpec, *hcldec.TransformFuncSpec:
+		return encodeError(fmt.Sprintf(DiagCannotEncodeTransformed, path))
+	case *hcldec.BlockSpec:
+		return encodeBlock(body, s.TypeName, nil, s.Nested, val, path)
+	case *hcldec.BlockListSpec:
+		return encodeBlocks(body, s.TypeName, s.Nested, val, path)
+	case *hcldec.BlockSetSpec:
+		return encodeBlocks(body, s.TypeName, s.Nested, val, path)
+	case *hcldec.BlockTupleSpec:
+		return encodeBlocks(body, s.TypeName, s.Nested, val, path)
+	case *hcldec.BlockMapSpec:
+		return encodeLabeledBlocks(body, s.TypeName, len(s.LabelNames), nil, s.Nested, val, path)
+	case *hcldec.BlockObjectSpec:
+		return encodeLabeledBlocks(body, s.TypeName, len(s.LabelNames), nil, s.Nested, val, path)
+	case *hcldec.BlockAttrsSpec:
+		if !val.Type().IsMapType() && !val.Type().IsObjectType() {
+			return encodeTypeError(path, "a map", val)
+		}
+
+		block := appendBlock(body, s.TypeName, nil)
+
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			block.Body().SetAttributeValue(key.AsString(), elem)
+		}
+	}
+
+	return nil
+}
+
+// encodeBlocks writes a block for each element of the list, set or tuple.
+func encodeBlocks(body *hclwrite.Body, typeName string, nested hcldec.Spec, val cty.Value, path string) hcl.Diagnostics {
+	if !val.CanIterateElements() || val.Type().IsMapType() || val.Type().IsObjectType() {
+		return encodeTypeError(path, "a list", val)
+	}
+
+	for it := val.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+
+		elemPath := path
+		if key.Type() == cty.Number {
+			elemPath = fmt.Sprintf("%s[%s]", path, key.AsBigFloat().String())
+		}
+
+		if diags := encodeBlock(body, typeName, nil, nested, elem, elemPath); diags.HasErrors() {
+			return diags
+		}
+	}
+
+	return nil
+}
+
+// encodeLabeledBlocks writes a block for each element of the map, or object, nested once for
+// each remaining label.
+func encodeLabeledBlocks(body *hclwrite.Body, typeName string, remaining int, labels []string, nested hcldec.Spec, val cty.Value, path string) hcl.Diagnostics {
+	if remaining == 0 {
+		return encodeBlock(body, typeName, labels, nested, val, path)
+	}
+
+	if val.IsNull() {
+		return nil
+	}
+
+	if !val.Type().IsMapType() && !val.Type().IsObjectType() {
+		return encodeTypeError(path, "a map", val)
+	}
+
+	for it := val.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+		label := key.AsString()
+
+		elemLabels := append(labels[:len(labels):len(labels)], label)
+		if diags := encodeLabeledBlocks(body, typeName, remaining-1, elemLabels, nested, elem, path+"."+label); diags.HasErrors() {
+			return diags
+		}
+	}
+
+	return nil
+}
+
+// encodeBlock writes a single block containing the value decoded by the nested spec.
+func encodeBlock(body *hclwrite.Body, typeName string, labels []string, nested hcldec.Spec, val cty.Value, path string) hcl.Diagnostics {
+	if val.IsNull() {
+		return nil
+	}
+
+	block := appendBlock(body, typeName, labels)
+	if nested == nil {
+		return nil
+	}
+
+	return encodeSpec(block.Body(), nested, val, path)
+}
+
+// appendBlock appends an empty block to the body, separated from the previous contents of the
+// body by an empty line.
+func appendBlock(body *hclwrite.Body, typeName string, labels []string) *hclwrite.Block {
+	if len(body.Attributes()) > 0 || len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+
+	return body.AppendNewBlock(typeName, labels)
+}
+
+// isBlockSpec returns true when the spec decodes one or more blocks.
+func isBlockSpec(spec hcldec.Spec) bool {
+	switch s := spec.(type) {
+	case *hcldec.BlockSpec, *hcldec.BlockListSpec, *hcldec.BlockSetSpec, *hcldec.BlockTupleSpec,
+		*hcldec.BlockMapSpec, *hcldec.BlockObjectSpec, *hcldec.BlockAttrsSpec:
+		return true
+	case *hcldec.DefaultSpec:
+		return isBlockSpec(s.Primary)
+	case *hcldec.ValidateSpec:
+		return isBlockSpec(s.Wrapped)
+	default:
+		return false
+	}
+}
+
+func encodeTypeError(path, want string, val cty.Value) hcl.Diagnostics {
+	return encodeError(fmt.Sprintf(DiagCannotEncodeType, path, want, val.Type().FriendlyName()))
+}
+
+func encodeError(detail string) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagCannotEncode,
+			Detail:   detail,
+		},
+	}
+}
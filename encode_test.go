@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type encodeUnit struct {
+	Callsign string `hcl:"callsign"`
+}
+
+type encodeStation struct {
+	ID      string       `hcl:"id,label"`
+	Name    string       `hcl:"name"`
+	Channel *int         `hcl:"channel,optional"`
+	Units   []encodeUnit `hcl:"unit,block"`
+}
+
+type encodeConfig struct {
+	Stations []encodeStation `hcl:"station,block"`
+}
+
+var encodeValue = cty.ObjectVal(map[string]cty.Value{
+	"station": cty.MapVal(map[string]cty.Value{
+		"st12": cty.ObjectVal(map[string]cty.Value{
+			"name":    cty.StringVal("Station 12"),
+			"channel": cty.NumberIntVal(12),
+			"unit": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{"callsign": cty.StringVal("M12")}),
+				cty.ObjectVal(map[string]cty.Value{"callsign": cty.StringVal("E12")}),
+			}),
+		}),
+		"st13": cty.ObjectVal(map[string]cty.Value{
+			"name":    cty.StringVal("Station 13"),
+			"channel": cty.NullVal(cty.Number),
+			"unit":    cty.ListValEmpty(cty.Object(map[string]cty.Type{"callsign": cty.String})),
+		}),
+	}),
+})
+
+const encodedSource = `station "st12" {
+  channel = 12
+  name    = "Station 12"
+
+  unit {
+    callsign = "M12"
+  }
+
+  unit {
+    callsign = "E12"
+  }
+}
+
+station "st13" {
+  name = "Station 13"
+}
+`
+
+func TestEncode(tt *testing.T) {
+	tt.Run("EncodeValue() writes the value as HCL", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+
+		src, diags := s.EncodeValue(encodeValue, spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, encodedSource, string(src))
+	})
+
+	tt.Run("EncodeValue() round trips through Parse in both syntaxes", func(t *testing.T) {
+		for _, syntax := range []spec.Syntax{spec.SyntaxHCL, spec.SyntaxJSON} {
+			s := spec.NewSubset(&stationSchema{})
+
+			src, diags := s.EncodeValue(encodeValue, syntax)
+			assert.False(t, diags.HasErrors())
+
+			filename := "stations.hcl"
+			if syntax == spec.SyntaxJSON {
+				filename = "stations.json"
+			}
+
+			assert.False(t, s.ParseSource(src, filename).HasErrors())
+			assert.False(t, s.Parse(&hcl.EvalContext{}).HasErrors())
+
+			val, hclDiags := hcldec.Decode(s.Body(), s.Build(), &hcl.EvalContext{})
+			assert.False(t, hclDiags.HasErrors())
+			assert.True(t, val.RawEquals(encodeValue), val.GoString())
+		}
+	})
+
+	tt.Run("EncodeValue() rejects values that do not match the spec", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+
+		for _, val := range []cty.Value{
+			cty.NilVal,
+			cty.StringVal("station"),
+			cty.ObjectVal(map[string]cty.Value{"station": cty.StringVal("st12")}),
+			cty.ObjectVal(map[string]cty.Value{"station": cty.MapVal(map[string]cty.Value{
+				"st12": cty.ObjectVal(map[string]cty.Value{"name": cty.UnknownVal(cty.String)}),
+			})}),
+			cty.ObjectVal(map[string]cty.Value{"station": cty.MapVal(map[string]cty.Value{
+				"st12": cty.ObjectVal(map[string]cty.Value{"channel": cty.NumberIntVal(12)}),
+			})}),
+		} {
+			src, diags := s.EncodeValue(val, spec.SyntaxHCL)
+			assert.Nil(t, src)
+			assert.True(t, diags.HasErrors(), val.GoString())
+		}
+
+		_, diags := s.EncodeValue(cty.StringVal("station"), spec.SyntaxHCL)
+		assert.Equal(t, spec.CodeCannotEncode, spec.CodeOf(diags.Raw()[0]))
+
+		src, diags := s.EncodeValue(encodeValue, spec.SyntaxUnknown)
+		assert.Nil(t, src)
+		assert.Equal(t, spec.CodeCannotDetermineFileType, spec.CodeOf(diags.Raw()[0]))
+	})
+
+	tt.Run("Encode() writes Go values that round trip through Decode", func(t *testing.T) {
+		channel := 12
+		config := &encodeConfig{
+			Stations: []encodeStation{
+				{ID: "st12", Name: "Station 12", Channel: &channel, Units: []encodeUnit{{Callsign: "M12"}}},
+				{ID: "st13", Name: "Station 13"},
+			},
+		}
+
+		s := spec.NewSubset(&stationSchema{})
+
+		src, diags := s.Encode(config, spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, "station \"st12\" {\n  channel = 12\n  name    = \"Station 12\"\n\n  unit {\n    callsign = \"M12\"\n  }\n}\n\nstation \"st13\" {\n  name = \"Station 13\"\n}\n", string(src))
+
+		assert.False(t, s.ParseSource(src, "stations.hcl").HasErrors())
+
+		decoded := &encodeConfig{}
+		assert.False(t, s.Decode(&hcl.EvalContext{}, decoded).HasErrors())
+		assert.Equal(t, config, decoded)
+	})
+
+	tt.Run("Encode() requires a struct matching the spec", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+
+		src, diags := s.Encode("station", spec.SyntaxHCL)
+		assert.Nil(t, src)
+		assert.Equal(t, spec.CodeCannotEncode, spec.CodeOf(diags.Raw()[0]))
+
+		type radio struct {
+			ID        string `hcl:"id,label"`
+			Name      string `hcl:"name"`
+			Frequency int    `hcl:"frequency"`
+		}
+
+		src, diags = s.Encode(&struct {
+			Stations []radio `hcl:"station,block"`
+		}{Stations: []radio{{ID: "st12", Name: "Station 12", Frequency: 154}}}, spec.SyntaxHCL)
+		assert.Nil(t, src)
+		assert.Equal(t, spec.CodeUnsupportedArgument, spec.CodeOf(diags.Raw()[0]))
+	})
+}
@@ -7,7 +7,6 @@
 package spec
 
 import (
-	"path"
 	"path/filepath"
 
 	"github.com/hashicorp/hcl/v2"
@@ -20,6 +19,8 @@ const (
 
 	DiagGlobError       = "There was a problem parsing the file pattern"
 	DiagGlobErrorDetail = "The file pattern was not able to be parsed. This might be an implementation problem."
+
+	DiagFailedToReadFile = "Failed to read file"
 )
 
 // Files accepts many file paths and processes each. All files provided will be processed
@@ -29,10 +30,10 @@ func (s *Spec) Files(filenames ...string) *Diagnostics {
 	diags := hcl.Diagnostics{}
 
 	for _, filename := range filenames {
-		switch ext := path.Ext(filename); ext {
-		case ".json":
+		switch SyntaxOf(filename) {
+		case SyntaxJSON:
 			diags = diags.Extend(s.parseJSONFile(filename))
-		case ".hcl":
+		case SyntaxHCL:
 			diags = diags.Extend(s.parseHCLFile(filename))
 		default:
 			diags = diags.Append(&hcl.Diagnostic{
@@ -43,7 +44,27 @@ func (s *Spec) Files(filenames ...string) *Diagnostics {
 		}
 	}
 
-	return newDiagnostics(s, diags)
+	return s.diagnostics(diags)
+}
+
+// ParseSource parses the raw src as either HCL or JSON based on the extension of the filename.
+// This is useful when the source is not read from the filesystem, such as an unsaved document
+// within an editor.
+func (s *Spec) ParseSource(src []byte, filename string) *Diagnostics {
+	switch SyntaxOf(filename) {
+	case SyntaxJSON:
+		return s.ParseJSON(src, filename)
+	case SyntaxHCL:
+		return s.ParseHCL(src, filename)
+	default:
+		return s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotDetermineFileType,
+				Detail:   DiagCannotDetermineFileTypeDetail,
+			},
+		})
+	}
 }
 
 // ParsedFiles returns all of the filenames that we've parsed through various parsing
@@ -43,3 +43,20 @@ func TestFileGlob(tt *testing.T) {
 		assert.Len(t, subset.ParsedFiles(), 4)
 	})
 }
+
+func TestParseSource(tt *testing.T) {
+	tt.Run("parses the source based on the extension", func(t *testing.T) {
+		subset := spec.NewSubset()
+
+		assert.False(t, subset.ParseSource([]byte(`one = "one"`), "one.hcl").HasErrors())
+		assert.False(t, subset.ParseSource([]byte(`{"two": "two"}`), "two.json").HasErrors())
+		assert.Len(t, subset.ParsedFiles(), 2)
+	})
+
+	tt.Run("an unknown extension results in a diagnostic error", func(t *testing.T) {
+		diags := spec.NewSubset().ParseSource([]byte(``), "three.txt")
+
+		assert.True(t, diags.HasErrors())
+		assert.Contains(t, diags.Error(), spec.DiagCannotDetermineFileType)
+	})
+}
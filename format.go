@@ -0,0 +1,165 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// diagnostic messages
+const (
+	DiagFileNotFormatted       = "File is not formatted"
+	DiagFileNotFormattedDetail = "The file does not match the canonical formatting, starting at this line. Format the file with \"spec fmt\"."
+	DiagCannotFormat           = "Cannot format file"
+	DiagCannotFormatDetail     = "The file is not valid JSON and cannot be formatted: %s"
+)
+
+// Syntax is the syntax of a configuration file.
+type Syntax int
+
+// supported syntaxes
+const (
+	SyntaxUnknown Syntax = iota
+	SyntaxHCL
+	SyntaxJSON
+)
+
+// SyntaxOf returns the Syntax of the file based on its extension. It is used wherever files are
+// handled differently by syntax so that the supported extensions are defined in one place.
+func SyntaxOf(filename string) Syntax {
+	switch path.Ext(filename) {
+	case ".hcl":
+		return SyntaxHCL
+	case ".json":
+		return SyntaxJSON
+	default:
+		return SyntaxUnknown
+	}
+}
+
+// Format returns the src rewritten with canonical formatting. HCL is formatted using hclwrite,
+// which aligns the equals signs of consecutive attributes and normalizes indentation, while
+// JSON is indented using two spaces with the order of properties kept. An error is returned
+// when the src is not valid syntax.
+func Format(src []byte, syntax Syntax) ([]byte, error) {
+	res, diags := format(src, "", syntax)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return res, nil
+}
+
+func format(src []byte, filename string, syntax Syntax) ([]byte, hcl.Diagnostics) {
+	switch syntax {
+	case SyntaxHCL:
+		if _, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos); diags.HasErrors() {
+			return nil, diags
+		}
+
+		return hclwrite.Format(src), nil
+	case SyntaxJSON:
+		buf := new(bytes.Buffer)
+		if err := json.Indent(buf, bytes.TrimSpace(src), "", "  "); err != nil {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  DiagCannotFormat,
+					Detail:   fmt.Sprintf(DiagCannotFormatDetail, err),
+					Subject:  &hcl.Range{Filename: filename, Start: hcl.InitialPos, End: hcl.InitialPos},
+				},
+			}
+		}
+
+		buf.WriteByte('\n')
+
+		return buf.Bytes(), nil
+	default:
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotDetermineFileType,
+				Detail:   DiagCannotDetermineFileTypeDetail,
+			},
+		}
+	}
+}
+
+// Format formats all parsed files and returns a ChangeSet containing those that changed.
+// Files that cannot be formatted are reported as diagnostics and left unchanged.
+func (s *Spec) Format() (*ChangeSet, *Diagnostics) {
+	changes := newChangeSet()
+	diags := hcl.Diagnostics{}
+
+	for _, filename := range s.filenames() {
+		src := s.files[filename].Bytes
+
+		res, formatDiags := format(src, filename, SyntaxOf(filename))
+		if formatDiags.HasErrors() {
+			diags = diags.Extend(formatDiags)
+			continue
+		}
+
+		if !bytes.Equal(src, res) {
+			changes.files[filename] = res
+		}
+	}
+
+	return changes, s.diagnostics(diags)
+}
+
+// CheckFormat returns an error diagnostic for each parsed file that does not match the
+// canonical formatting of Format. The subject of each diagnostic is the first line that
+// differs.
+func (s *Spec) CheckFormat() *Diagnostics {
+	changes, diags := s.Format()
+	res := hcl.Diagnostics{}
+
+	for _, filename := range changes.Filenames() {
+		rng := firstDifference(filename, s.files[filename].Bytes, changes.Bytes(filename))
+
+		res = res.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  DiagFileNotFormatted,
+			Detail:   DiagFileNotFormattedDetail,
+			Subject:  &rng,
+		})
+	}
+
+	return diags.Merge(s.diagnostics(res))
+}
+
+// firstDifference returns the range of the line within a containing the first byte that differs
+// from b.
+func firstDifference(filename string, a, b []byte) hcl.Range {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	start := bytes.LastIndexByte(a[:i], '\n') + 1
+
+	end := len(a)
+	if j := bytes.IndexByte(a[start:], '\n'); j >= 0 {
+		end = start + j
+	}
+
+	line := bytes.Count(a[:start], []byte{'\n'}) + 1
+
+	return hcl.Range{
+		Filename: filename,
+		Start:    hcl.Pos{Line: line, Column: 1, Byte: start},
+		End:      hcl.Pos{Line: line, Column: len([]rune(string(a[start:end]))) + 1, Byte: end},
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntaxOf(tt *testing.T) {
+	tt.Run("SyntaxOf() uses the extension of the file", func(t *testing.T) {
+		assert.Equal(t, spec.SyntaxHCL, spec.SyntaxOf("stations.hcl"))
+		assert.Equal(t, spec.SyntaxJSON, spec.SyntaxOf("stations.json"))
+		assert.Equal(t, spec.SyntaxUnknown, spec.SyntaxOf("stations.txt"))
+	})
+}
+
+func TestFormat(tt *testing.T) {
+	tt.Run("Format() aligns and indents HCL", func(t *testing.T) {
+		res, err := spec.Format([]byte("unit {\nname = \"medic-12\"\n    channel=12\n}\n"), spec.SyntaxHCL)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "unit {\n  name    = \"medic-12\"\n  channel = 12\n}\n", string(res))
+	})
+
+	tt.Run("Format() indents JSON and keeps the order of properties", func(t *testing.T) {
+		res, err := spec.Format([]byte(`{"unit": {"name": "medic-12", "channel": 12}}`), spec.SyntaxJSON)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"unit\": {\n    \"name\": \"medic-12\",\n    \"channel\": 12\n  }\n}\n", string(res))
+	})
+
+	tt.Run("Format() returns an error for invalid syntax", func(t *testing.T) {
+		_, err := spec.Format([]byte("unit {\n"), spec.SyntaxHCL)
+		assert.Error(t, err)
+
+		_, err = spec.Format([]byte(`{"unit": `), spec.SyntaxJSON)
+		assert.Error(t, err)
+
+		_, err = spec.Format([]byte(`unit {}`), spec.SyntaxUnknown)
+		assert.Error(t, err)
+	})
+
+	tt.Run("Format() reports parsed JSON files that cannot be formatted", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseSource([]byte(`{"unit": `), "unit.json")
+
+		changes, diags := s.Format()
+
+		assert.Equal(t, 0, changes.Len())
+		assert.Equal(t, []spec.Code{spec.CodeCannotFormat}, diagnosticCodes(diags))
+		assert.Equal(t, "unit.json", diags.Raw()[0].Subject.Filename)
+	})
+
+	tt.Run("Format() returns the parsed files that change", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		assert.False(t, s.Files("./testdata/stations/stations.hcl", "./testdata/stations/stations.json").HasErrors())
+		s.ParseHCL([]byte("unit {\nname = \"medic-12\"\n}\n"), "unit.hcl")
+
+		changes, diags := s.Format()
+
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, []string{"unit.hcl"}, changes.Filenames())
+		assert.Equal(t, "unit {\n  name = \"medic-12\"\n}\n", string(changes.Bytes("unit.hcl")))
+	})
+}
+
+func TestCheckFormat(tt *testing.T) {
+	tt.Run("CheckFormat() reports the first line that is not formatted", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("# units\nunit {\n  name = \"medic-12\"\n    channel = 12\n}\n"), "unit.hcl")
+		s.ParseJSON([]byte("{\n  \"unit\": {\n    \"name\": \"medic-14\"\n  }\n}\n"), "unit.json")
+
+		diags := s.CheckFormat()
+
+		assert.Len(t, diags.Raw(), 1)
+		assert.Equal(t, spec.CodeFileNotFormatted, spec.CodeOf(diags.Raw()[0]))
+		assert.Equal(t, "unit.hcl", diags.Raw()[0].Subject.Filename)
+		assert.Equal(t, 3, diags.Raw()[0].Subject.Start.Line)
+		assert.Equal(t, 20, diags.Raw()[0].Subject.End.Column)
+	})
+}
@@ -16,19 +16,21 @@ import (
 
 // ParseHCL parses the raw src as HCL.
 func (s *Spec) ParseHCL(src []byte, filename string) *Diagnostics {
-	return newDiagnostics(s, s.parseHCL(src, filename))
+	return s.diagnostics(s.parseHCL(src, filename))
 }
 
 func (s *Spec) parseHCL(src []byte, filename string) hcl.Diagnostics {
 	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
 	s.files[filename] = file
+	delete(s.migrated, filename)
+	s.suppressions[filename] = findSuppressions(src, filename)
 
 	return diags
 }
 
 // ParseHCLFile parses a single HCL file by reading it from the filesystem.
 func (s *Spec) ParseHCLFile(filename string) *Diagnostics {
-	return newDiagnostics(s, s.parseHCLFile(filename))
+	return s.diagnostics(s.parseHCLFile(filename))
 }
 
 func (s *Spec) parseHCLFile(filename string) hcl.Diagnostics {
@@ -37,7 +39,7 @@ func (s *Spec) parseHCLFile(filename string) hcl.Diagnostics {
 		return hcl.Diagnostics{
 			{
 				Severity: hcl.DiagError,
-				Summary:  "Failed to read file",
+				Summary:  DiagFailedToReadFile,
 				Detail:   fmt.Sprintf("The HCL file %q could not be read.", filename),
 			},
 		}
@@ -16,19 +16,21 @@ import (
 
 // ParseJSON parses the raw src as JSON.
 func (s *Spec) ParseJSON(src []byte, filename string) *Diagnostics {
-	return newDiagnostics(s, s.parseJSON(src, filename))
+	return s.diagnostics(s.parseJSON(src, filename))
 }
 
 func (s *Spec) parseJSON(src []byte, filename string) hcl.Diagnostics {
 	file, diags := json.Parse(src, filename)
 	s.files[filename] = file
+	delete(s.migrated, filename)
+	delete(s.suppressions, filename)
 
 	return diags
 }
 
 // ParseJSONFile parses a single JSON file by reading it from the filesystem.
 func (s *Spec) ParseJSONFile(filename string) *Diagnostics {
-	return newDiagnostics(s, s.parseJSONFile(filename))
+	return s.diagnostics(s.parseJSONFile(filename))
 }
 
 func (s *Spec) parseJSONFile(filename string) hcl.Diagnostics {
@@ -37,7 +39,7 @@ func (s *Spec) parseJSONFile(filename string) hcl.Diagnostics {
 		return hcl.Diagnostics{
 			{
 				Severity: hcl.DiagError,
-				Summary:  "Failed to read file",
+				Summary:  DiagFailedToReadFile,
 				Detail:   fmt.Sprintf("The JSON file %q could not be read.", filename),
 			},
 		}
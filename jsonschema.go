@@ -0,0 +1,228 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// JSONSchemaDraft is the JSON Schema draft used by JSONSchema.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// jsonSchemaComment is the property HCL allows within JSON bodies for comments.
+const jsonSchemaComment = "//"
+
+// JSONSchema is a JSON Schema document or subschema. Only the keywords needed to describe
+// configuration files are included.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                interface{}            `json:"items,omitempty"`
+	MinItems             int                    `json:"minItems,omitempty"`
+	MaxItems             int                    `json:"maxItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema describing the JSON form of configuration files for the
+// built hcldec.Spec. This allows configuration files to be validated and completed by tools
+// outside of Go. As JSON strings may contain template expressions, such as "${station.st12.id}",
+// attributes that are not strings also accept strings containing an interpolation sequence.
+//
+// The schema is limited to the structure of the configuration so a file that satisfies it may
+// still produce diagnostics when parsed, such as for an unknown variable. Blocks and attributes
+// documented by parser.Metadata include their description, which starts with "Deprecated:" when
+// they are deprecated.
+func (s *Spec) JSONSchema() *JSONSchema {
+	res := s.jsonSchemaBody(parser.Introspect(s.Build()))
+	res.Schema = JSONSchemaDraft
+
+	if s.versioned() {
+		res.Properties[VersionAttribute] = &JSONSchema{Type: "integer"}
+	}
+
+	return res
+}
+
+// WriteJSON writes the schema as an indented JSON document to the provided io.Writer.
+func (j *JSONSchema) WriteJSON(to io.Writer) error {
+	enc := json.NewEncoder(to)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(j)
+}
+
+// jsonSchemaBody returns the schema of a body.
+func (s *Spec) jsonSchemaBody(body *parser.SchemaBody) *JSONSchema {
+	res := &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			jsonSchemaComment: {Type: "string"},
+		},
+		Required:             []string{},
+		AdditionalProperties: false,
+	}
+
+	for name, attr := range body.Attributes {
+		res.Properties[name] = s.jsonSchemaDocument(jsonSchemaExpression(attr.Type), attr.Path)
+
+		if attr.Required {
+			res.Required = append(res.Required, name)
+		}
+	}
+
+	for name, block := range body.Blocks {
+		res.Properties[name] = s.jsonSchemaDocument(s.jsonSchemaBlock(block), block.Path)
+
+		if block.Required {
+			res.Required = append(res.Required, name)
+		}
+	}
+
+	// validation blocks are allowed within every block which does not describe its own
+	if _, ok := res.Properties[ValidationBlock]; s.validations && body.Path != "" && !ok {
+		validation := &JSONSchema{
+			Type: "object",
+			Properties: map[string]*JSONSchema{
+				jsonSchemaComment: {Type: "string"},
+				"condition":       jsonSchemaExpression(cty.Bool),
+				"error_message":   {Type: "string"},
+			},
+			Required:             []string{"condition", "error_message"},
+			AdditionalProperties: false,
+		}
+
+		res.Properties[ValidationBlock] = &JSONSchema{
+			AnyOf: []*JSONSchema{validation, {Type: "array", Items: validation}},
+		}
+	}
+
+	sort.Strings(res.Required)
+
+	return res
+}
+
+// jsonSchemaBlock returns the schema of the value of the block's property.
+func (s *Spec) jsonSchemaBlock(block *parser.SchemaBlock) *JSONSchema {
+	switch block.Nesting {
+	case parser.NestingSingle:
+		return s.jsonSchemaBody(block.Body)
+	case parser.NestingList, parser.NestingSet, parser.NestingTuple:
+		return s.jsonSchemaBlocks(block.Body, block.MinItems, block.MaxItems)
+	case parser.NestingMap, parser.NestingObject:
+		return s.jsonSchemaLabels(block.Body, len(block.LabelNames))
+	case parser.NestingAttrs:
+		return &JSONSchema{
+			Type:                 "object",
+			AdditionalProperties: jsonSchemaExpression(block.ElementType),
+		}
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// jsonSchemaBlocks returns the schema of a block that may be repeated. A single block may be
+// written as an object while multiple blocks are written as an array of objects.
+func (s *Spec) jsonSchemaBlocks(body *parser.SchemaBody, minItems, maxItems int) *JSONSchema {
+	res := s.jsonSchemaBody(body)
+
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{
+			res,
+			{Type: "array", Items: res, MinItems: minItems, MaxItems: maxItems},
+		},
+	}
+}
+
+// jsonSchemaLabels returns the schema of a labeled block where each label is a nested object
+// property.
+func (s *Spec) jsonSchemaLabels(body *parser.SchemaBody, labels int) *JSONSchema {
+	res := s.jsonSchemaBody(body)
+
+	for i := 0; i < labels; i++ {
+		res = &JSONSchema{Type: "object", AdditionalProperties: res}
+	}
+
+	return res
+}
+
+// jsonSchemaDocument adds the documentation of the block or attribute at the path to its schema.
+func (s *Spec) jsonSchemaDocument(schema *JSONSchema, path string) *JSONSchema {
+	doc := s.Documentation(path)
+	if doc == nil {
+		return schema
+	}
+
+	schema.Description = doc.Description
+
+	// draft-07 does not define the deprecated keyword so deprecations are only described
+	if doc.Deprecation != "" {
+		schema.Description = strings.TrimSpace("Deprecated: " + doc.Deprecation + " " + doc.Description)
+	}
+
+	return schema
+}
+
+// jsonSchemaExpression returns the schema of an attribute of the given type.
+func jsonSchemaExpression(ty cty.Type) *JSONSchema {
+	res := jsonSchemaType(ty)
+	if res.Type == "" || res.Type == "string" {
+		return res
+	}
+
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{res, {Type: "string", Pattern: `\$\{`}},
+	}
+}
+
+// jsonSchemaType returns the schema of a value of the cty.Type.
+func jsonSchemaType(ty cty.Type) *JSONSchema {
+	switch {
+	case ty == cty.String:
+		return &JSONSchema{Type: "string"}
+	case ty == cty.Number:
+		return &JSONSchema{Type: "number"}
+	case ty == cty.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case ty.IsListType() || ty.IsSetType():
+		return &JSONSchema{Type: "array", Items: jsonSchemaType(ty.ElementType())}
+	case ty.IsMapType():
+		return &JSONSchema{Type: "object", AdditionalProperties: jsonSchemaType(ty.ElementType())}
+	case ty.IsTupleType():
+		items := []*JSONSchema{}
+		for _, elem := range ty.TupleElementTypes() {
+			items = append(items, jsonSchemaType(elem))
+		}
+
+		return &JSONSchema{Type: "array", Items: items, MinItems: len(items), MaxItems: len(items)}
+	case ty.IsObjectType():
+		res := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, Required: []string{}}
+
+		for name, attr := range ty.AttributeTypes() {
+			res.Properties[name] = jsonSchemaType(attr)
+			res.Required = append(res.Required, name)
+		}
+
+		sort.Strings(res.Required)
+
+		return res
+	default:
+		// cty.DynamicPseudoType accepts any value
+		return &JSONSchema{}
+	}
+}
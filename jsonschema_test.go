@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type settingsSchema struct{}
+
+func (s *settingsSchema) Name() string {
+	return "settings"
+}
+
+func (s *settingsSchema) Spec() hcldec.Spec {
+	return hcldec.ObjectSpec{
+		"timezone": &hcldec.AttrSpec{Name: "timezone", Type: cty.String, Required: true},
+		"settings": &hcldec.BlockSpec{
+			TypeName: "settings",
+			Required: true,
+			Nested: hcldec.ObjectSpec{
+				"tags":   &hcldec.AttrSpec{Name: "tags", Type: cty.List(cty.String)},
+				"limits": &hcldec.AttrSpec{Name: "limits", Type: cty.Map(cty.Number)},
+				"enabled": &hcldec.DefaultSpec{
+					Primary: &hcldec.AttrSpec{Name: "enabled", Type: cty.Bool},
+					Default: &hcldec.LiteralSpec{Value: cty.True},
+				},
+			},
+		},
+		"labels": &hcldec.BlockAttrsSpec{TypeName: "labels", ElementType: cty.String},
+	}
+}
+
+func TestJSONSchema(tt *testing.T) {
+	tt.Run("JSONSchema() describes attributes and blocks", func(t *testing.T) {
+		schema := spec.NewSubset(&settingsSchema{}).JSONSchema()
+
+		assert.Equal(t, spec.JSONSchemaDraft, schema.Schema)
+		assert.Equal(t, "object", schema.Type)
+		assert.Equal(t, false, schema.AdditionalProperties)
+		assert.Equal(t, []string{"settings", "timezone"}, schema.Required)
+		assert.Equal(t, "string", schema.Properties["timezone"].Type)
+		assert.Equal(t, "string", schema.Properties["//"].Type)
+
+		settings := schema.Properties["settings"]
+		assert.Empty(t, settings.Required)
+		assert.Equal(t, "array", settings.Properties["tags"].AnyOf[0].Type)
+		assert.Equal(t, "string", settings.Properties["tags"].AnyOf[0].Items.(*spec.JSONSchema).Type)
+		assert.Equal(t, `\$\{`, settings.Properties["tags"].AnyOf[1].Pattern)
+		assert.Equal(t, "number", settings.Properties["limits"].AnyOf[0].AdditionalProperties.(*spec.JSONSchema).Type)
+		assert.Equal(t, "boolean", settings.Properties["enabled"].AnyOf[0].Type)
+
+		labels := schema.Properties["labels"]
+		assert.Equal(t, "object", labels.Type)
+		assert.Equal(t, "string", labels.AdditionalProperties.(*spec.JSONSchema).Type)
+	})
+
+	tt.Run("JSONSchema() describes labeled and repeated blocks", func(t *testing.T) {
+		schema := spec.NewSubset(&stationSchema{}).JSONSchema()
+
+		station := schema.Properties["station"]
+		assert.Equal(t, "object", station.Type)
+
+		body := station.AdditionalProperties.(*spec.JSONSchema)
+		assert.Equal(t, []string{"name"}, body.Required)
+		assert.Equal(t, "number", body.Properties["channel"].AnyOf[0].Type)
+
+		unit := body.Properties["unit"]
+		assert.Len(t, unit.AnyOf, 2)
+		assert.Equal(t, []string{"callsign"}, unit.AnyOf[0].Required)
+		assert.Equal(t, "array", unit.AnyOf[1].Type)
+		assert.Equal(t, unit.AnyOf[0], unit.AnyOf[1].Items)
+	})
+
+	tt.Run("WriteJSON() writes the schema document", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, spec.NewSubset(&stationSchema{}).JSONSchema().WriteJSON(b))
+
+		out := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(b.Bytes(), &out))
+		assert.Equal(t, spec.JSONSchemaDraft, out["$schema"])
+		assert.Equal(t, false, out["additionalProperties"])
+	})
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Location describes the configuration at a position within a parsed file along with the part
+// of the hcldec.Spec that governs it. It is the basis for editor integrations such as completion,
+// hover and go-to-definition and works for both HCL and JSON files.
+type Location struct {
+	Filename string
+	Pos      hcl.Pos
+
+	// Blocks contains the blocks enclosing the position, outermost first, and Path contains the
+	// type and labels of each of them followed by the name of the attribute, if any. SchemaPath
+	// is the Path with each label replaced by parser.SchemaWildcard, as used by Documentation and
+	// parser.SchemaBody.Lookup.
+	Blocks     []*hcl.Block
+	Path       []string
+	SchemaPath []string
+
+	// Attribute is the attribute at the position, Expression the innermost expression within
+	// it containing the position and Traversal the variable reference containing the position.
+	Attribute  *hcl.Attribute
+	Expression hcl.Expression
+	Traversal  hcl.Traversal
+
+	// Spec is the hcldec.Spec governing the innermost block or attribute at the position, with
+	// BlockType and AttributeName being their names. Type is the type expected of the attribute
+	// and is cty.NilType when there is no attribute or the type is not known.
+	Spec          hcldec.Spec
+	BlockType     string
+	AttributeName string
+	Type          cty.Type
+
+	// BodySpec is the hcldec.Spec describing the contents of the innermost body containing the
+	// position. It is nil when the body may contain any attribute, in which case ElementType is
+	// the type expected of each of them.
+	BodySpec    hcldec.Spec
+	ElementType cty.Type
+}
+
+// At returns the Location of the given position within a parsed file. Positions without a byte
+// offset are resolved using their line and column. Nil is returned when the file has not been
+// parsed.
+func (s *Spec) At(filename string, pos hcl.Pos) *Location {
+	file := s.migratedFile(filename)
+	if file == nil {
+		return nil
+	}
+
+	loc := &Location{
+		Filename:    filename,
+		Pos:         normalizePos(file.Bytes, pos),
+		Type:        cty.NilType,
+		ElementType: cty.NilType,
+		BodySpec:    s.Build(),
+	}
+
+	loc.walk(file.Body, loc.BodySpec)
+
+	return loc
+}
+
+// Block returns the innermost block containing the position or nil when the position is not
+// within a block.
+func (l *Location) Block() *hcl.Block {
+	if len(l.Blocks) == 0 {
+		return nil
+	}
+
+	return l.Blocks[len(l.Blocks)-1]
+}
+
+// walk descends into the attribute or block of the body containing the position.
+func (l *Location) walk(body hcl.Body, spec hcldec.Spec) {
+	schema := parser.Introspect(spec)
+
+	content, remain, _ := body.PartialContent(hcldec.ImpliedSchema(spec))
+	if content == nil {
+		return
+	}
+
+	for _, attr := range content.Attributes {
+		if containsPos(attr.Range, l.Pos) {
+			l.setAttribute(attr, schema.Attributes[attr.Name])
+			return
+		}
+	}
+
+	for _, block := range content.Blocks {
+		if !containsPos(blockRange(block), l.Pos) {
+			continue
+		}
+
+		sb := schema.Blocks[block.Type]
+		if sb == nil {
+			return
+		}
+
+		l.Blocks = append(l.Blocks, block)
+		l.Path = append(append(l.Path, block.Type), block.Labels...)
+		l.SchemaPath = append(l.SchemaPath, block.Type)
+		for range block.Labels {
+			l.SchemaPath = append(l.SchemaPath, parser.SchemaWildcard)
+		}
+		l.Spec = sb.Spec
+		l.BlockType = block.Type
+		l.BodySpec = nil
+
+		if sb.Body != nil {
+			l.BodySpec = sb.Body.Spec
+			l.walk(block.Body, sb.Body.Spec)
+			return
+		}
+
+		// a block of arbitrary attributes such as a hcldec.BlockAttrsSpec
+		l.ElementType = sb.ElementType
+
+		attrs, _ := block.Body.JustAttributes()
+		for _, attr := range attrs {
+			if containsPos(attr.Range, l.Pos) {
+				l.setAttribute(attr, nil)
+				l.Type = sb.ElementType
+			}
+		}
+
+		return
+	}
+
+	// attributes not described by the spec are still located so their expressions can be
+	// inspected, such as when hovering over a variable.
+	attrs, _ := remain.JustAttributes()
+	for _, attr := range attrs {
+		if containsPos(attr.Range, l.Pos) {
+			l.setAttribute(attr, nil)
+		}
+	}
+}
+
+func (l *Location) setAttribute(attr *hcl.Attribute, schema *parser.SchemaAttribute) {
+	l.Attribute = attr
+	l.AttributeName = attr.Name
+	l.Path = append(l.Path, attr.Name)
+	l.SchemaPath = append(l.SchemaPath, attr.Name)
+	l.Expression = innermostExpression(attr.Expr, l.Pos)
+
+	if schema != nil {
+		l.Spec = schema.Spec
+		l.Type = schema.Type
+	}
+
+	for _, traversal := range attr.Expr.Variables() {
+		if containsPos(traversal.SourceRange(), l.Pos) {
+			l.Traversal = traversal
+		}
+	}
+}
+
+// innermostExpression returns the smallest expression within expr containing the position.
+// Only native syntax expressions can be descended into.
+func innermostExpression(expr hcl.Expression, pos hcl.Pos) hcl.Expression {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return expr
+	}
+
+	best := expr
+
+	_ = hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		e, ok := n.(hclsyntax.Expression)
+		if ok && containsPos(e.Range(), pos) && rangeSize(e.Range()) <= rangeSize(best.Range()) {
+			best = e
+		}
+
+		return nil
+	})
+
+	return best
+}
+
+// blockRange returns the range of the entire block from its type to the end of its body.
+func blockRange(block *hcl.Block) hcl.Range {
+	if body, ok := block.Body.(*hclsyntax.Body); ok {
+		return hcl.RangeBetween(block.TypeRange, body.SrcRange)
+	}
+
+	return hcl.RangeBetween(block.TypeRange, block.Body.MissingItemRange())
+}
+
+// containsPos returns true when the position is within the range, including its end so that
+// a cursor placed directly after a construct is considered to be within it.
+func containsPos(rng hcl.Range, pos hcl.Pos) bool {
+	return pos.Byte >= rng.Start.Byte && pos.Byte <= rng.End.Byte
+}
+
+func rangeSize(rng hcl.Range) int {
+	return rng.End.Byte - rng.Start.Byte
+}
+
+// normalizePos resolves the byte offset of a position using its line and column when the
+// position does not include one. Columns are counted in runes and offsets beyond the source
+// are moved to its end.
+func normalizePos(src []byte, pos hcl.Pos) hcl.Pos {
+	if pos.Byte < 0 || pos.Byte > len(src) {
+		pos.Byte = len(src)
+		return pos
+	}
+
+	if pos.Byte != 0 || (pos.Line <= 1 && pos.Column <= 1) {
+		return pos
+	}
+
+	line, column := 1, 1
+
+	for i, r := range string(src) {
+		if line == pos.Line && (column == pos.Column || r == '\n') {
+			pos.Byte = i
+			return pos
+		}
+
+		if r == '\n' {
+			line++
+			column = 1
+
+			continue
+		}
+
+		column++
+	}
+
+	pos.Byte = len(src)
+
+	return pos
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type stationSchema struct{}
+
+func (s *stationSchema) Name() string {
+	return "station"
+}
+
+func (s *stationSchema) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "station",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"name":    &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+			"channel": &hcldec.AttrSpec{Name: "channel", Type: cty.Number},
+			"unit": &hcldec.BlockListSpec{
+				TypeName: "unit",
+				Nested: hcldec.ObjectSpec{
+					"callsign": &hcldec.AttrSpec{Name: "callsign", Type: cty.String, Required: true},
+				},
+			},
+		},
+	}
+}
+
+func (s *stationSchema) Variables(v cty.Value) parser.InjectableVariables {
+	return parser.InjectableVariables{"station": v}
+}
+
+func (s *stationSchema) InjectedVariables() []string {
+	return []string{"station"}
+}
+
+func (s *stationSchema) InjectedFunctions() parser.InjectableFunctions {
+	return nil
+}
+
+func TestAt(tt *testing.T) {
+	s := spec.NewSubset(&stationSchema{})
+	assert.False(tt, s.Files("./testdata/stations/stations.hcl", "./testdata/stations/stations.json").HasErrors())
+
+	tt.Run("At() returns nil for files that were not parsed", func(t *testing.T) {
+		assert.Nil(t, s.At("./testdata/stations/missing.hcl", hcl.Pos{Line: 1, Column: 1}))
+	})
+
+	tt.Run("At() finds the attribute and its expected type", func(t *testing.T) {
+		loc := s.At("./testdata/stations/stations.hcl", hcl.Pos{Line: 3, Column: 14})
+
+		assert.Equal(t, "channel", loc.AttributeName)
+		assert.Equal(t, "station", loc.BlockType)
+		assert.Equal(t, cty.Number, loc.Type)
+		assert.Equal(t, []string{"station", "st12", "channel"}, loc.Path)
+		assert.Equal(t, "st12", loc.Block().Labels[0])
+	})
+
+	tt.Run("At() finds the innermost expression and traversal", func(t *testing.T) {
+		loc := s.At("./testdata/stations/stations.hcl", hcl.Pos{Line: 6, Column: 36})
+
+		assert.Equal(t, "callsign", loc.AttributeName)
+		assert.Equal(t, "unit", loc.BlockType)
+		assert.Len(t, loc.Blocks, 2)
+		assert.IsType(t, &hclsyntax.ScopeTraversalExpr{}, loc.Expression)
+		assert.Equal(t, "station", loc.Traversal.RootName())
+	})
+
+	tt.Run("At() returns the body spec for positions between attributes", func(t *testing.T) {
+		loc := s.At("./testdata/stations/stations.hcl", hcl.Pos{Line: 4, Column: 1})
+
+		assert.Nil(t, loc.Attribute)
+		assert.Equal(t, "station", loc.BlockType)
+		assert.IsType(t, hcldec.ObjectSpec{}, loc.BodySpec)
+		assert.IsType(t, &hcldec.BlockMapSpec{}, loc.Spec)
+	})
+
+	tt.Run("At() works for JSON files", func(t *testing.T) {
+		loc := s.At("./testdata/stations/stations.json", hcl.Pos{Line: 8, Column: 24})
+
+		assert.Equal(t, "callsign", loc.AttributeName)
+		assert.Equal(t, cty.String, loc.Type)
+		assert.Equal(t, []string{"station", "st14", "unit", "callsign"}, loc.Path)
+	})
+}
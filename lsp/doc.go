@@ -0,0 +1,14 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package lsp provides a Language Server Protocol server for configuration files described
+// by a spec.Spec. The server publishes diagnostics as documents are edited and offers
+// completion and hover information based on the registered BlockDefinition's and the
+// variables and functions they inject.
+//
+// Only the subset of the protocol needed by the server is implemented and documents are
+// always synchronized in full.
+package lsp
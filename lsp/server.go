@@ -0,0 +1,575 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// diagnosticSource is the source shown by clients for published diagnostics.
+const diagnosticSource = "spec"
+
+// renameFailed is the error message of a failed rename whose diagnostics were suppressed.
+const renameFailed = "The name at the position cannot be renamed."
+
+// Server is a Language Server Protocol server for documents described by a set of
+// parser.NamedBlockDefinitions. All open documents are parsed together as a single
+// configuration so variables injected by one document are available in the others.
+type Server struct {
+	defs      parser.NamedBlockDefinitions
+	documents map[string]*document
+	spec      *spec.Spec
+	ctx       *hcl.EvalContext
+	conn      *conn
+}
+
+// document is a document opened by the client.
+type document struct {
+	uri      string
+	filename string
+	text     []byte
+}
+
+// NewServer creates a new Server for documents described by the pre-ordered
+// parser.NamedBlockDefinitions.
+func NewServer(defs parser.NamedBlockDefinitions) *Server {
+	return &Server{
+		defs:      defs,
+		documents: map[string]*document{},
+		spec:      spec.New(defs),
+		ctx:       &hcl.EvalContext{},
+	}
+}
+
+// Serve reads requests from in and writes responses and notifications to out until the
+// client sends the exit notification or in is closed.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.conn = newConn(in, out)
+
+	for {
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if rerr, ok := err.(*responseError); ok {
+			if err := s.conn.replyError(nil, rerr); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches the message to the appropriate handler.
+func (s *Server) handle(msg *message) error {
+	switch msg.Method {
+	case "initialize":
+		return s.conn.reply(msg.ID, &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncFull,
+				CompletionProvider: completionProvider{
+					TriggerCharacters: []string{"."},
+				},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				ReferencesProvider: true,
+				RenameProvider:     true,
+			},
+			ServerInfo: serverInfo{Name: "spec-lsp"},
+		})
+	case "shutdown":
+		return s.conn.reply(msg.ID, nil)
+	case "textDocument/didOpen":
+		params := &didOpenParams{}
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			return nil
+		}
+
+		s.documents[params.TextDocument.URI] = newDocument(params.TextDocument.URI, params.TextDocument.Text)
+
+		return s.update(params.TextDocument.URI)
+	case "textDocument/didChange":
+		params := &didChangeParams{}
+		if err := json.Unmarshal(msg.Params, params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		s.documents[params.TextDocument.URI] = newDocument(params.TextDocument.URI, text)
+
+		return s.update(params.TextDocument.URI)
+	case "textDocument/didClose":
+		params := &didCloseParams{}
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			return nil
+		}
+
+		delete(s.documents, params.TextDocument.URI)
+
+		if err := s.publish(params.TextDocument.URI, nil); err != nil {
+			return err
+		}
+
+		return s.update(params.TextDocument.URI)
+	case "textDocument/completion":
+		return s.respond(msg, func(doc *document, pos hcl.Pos) interface{} {
+			return s.completion(doc, pos)
+		})
+	case "textDocument/hover":
+		return s.respond(msg, func(doc *document, pos hcl.Pos) interface{} {
+			if hover := s.hover(doc, pos); hover != nil {
+				return hover
+			}
+
+			return nil
+		})
+	case "textDocument/definition":
+		return s.respond(msg, func(doc *document, pos hcl.Pos) interface{} {
+			if loc := s.definition(doc, pos); loc != nil {
+				return loc
+			}
+
+			return nil
+		})
+	case "textDocument/references":
+		params := &referenceParams{}
+		if err := json.Unmarshal(msg.Params, params); err != nil {
+			return s.conn.replyError(msg.ID, &responseError{Code: codeInvalidParams, Message: err.Error()})
+		}
+
+		return s.respond(msg, func(doc *document, pos hcl.Pos) interface{} {
+			return s.references(doc, pos, params.Context.IncludeDeclaration)
+		})
+	case "textDocument/rename":
+		return s.rename(msg)
+	default:
+		if msg.ID != nil {
+			return s.conn.replyError(msg.ID, &responseError{
+				Code:    codeMethodNotFound,
+				Message: fmt.Sprintf("method %q is not supported", msg.Method),
+			})
+		}
+
+		// unsupported notifications are ignored
+		return nil
+	}
+}
+
+// respond replies to a request made at a position within a document with the result of fn.
+func (s *Server) respond(msg *message, fn func(doc *document, pos hcl.Pos) interface{}) error {
+	params := &textDocumentPositionParams{}
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return s.conn.replyError(msg.ID, &responseError{Code: codeInvalidParams, Message: err.Error()})
+	}
+
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return s.conn.reply(msg.ID, nil)
+	}
+
+	pos := hcl.Pos{
+		Line:   params.Position.Line + 1,
+		Column: params.Position.Character + 1,
+		Byte:   doc.offset(params.Position),
+	}
+
+	return s.conn.reply(msg.ID, fn(doc, pos))
+}
+
+// update parses all open documents and publishes their diagnostics after the document with the
+// given uri was opened, changed or closed.
+func (s *Server) update(uri string) error {
+	sp := spec.New(s.defs)
+	diags := &spec.Diagnostics{Spec: sp}
+
+	for _, doc := range s.sortedDocuments() {
+		diags = diags.Merge(sp.ParseSource(doc.text, doc.filename))
+	}
+
+	ctx := &hcl.EvalContext{}
+	diags = diags.Merge(sp.Parse(ctx))
+
+	s.spec = sp
+	s.ctx = ctx
+
+	groups := diags.Dedupe().GroupByFile()
+	docs := s.sortedDocuments()
+
+	// diagnostics without a subject are shown at the start of the document being updated or,
+	// once it is closed, of the first open document
+	if general, ok := groups[""]; ok && len(docs) > 0 {
+		target, ok := s.documents[uri]
+		if !ok {
+			target = docs[0]
+		}
+
+		groups[target.filename] = general.Merge(groups[target.filename])
+	}
+
+	for _, doc := range docs {
+		if err := s.publish(doc.uri, groups[doc.filename]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publish publishes the diagnostics for the document with the given uri.
+func (s *Server) publish(uri string, diags *spec.Diagnostics) error {
+	params := &publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: []*Diagnostic{},
+	}
+
+	if diags != nil {
+		for _, diag := range diags.Raw() {
+			params.Diagnostics = append(params.Diagnostics, newDiagnostic(diag))
+		}
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", params)
+}
+
+func (s *Server) sortedDocuments() []*document {
+	docs := make([]*document, 0, len(s.documents))
+
+	for _, doc := range s.documents {
+		docs = append(docs, doc)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].uri < docs[j].uri
+	})
+
+	return docs
+}
+
+// completionKinds maps the kinds of spec.Candidate to completion item kinds.
+var completionKinds = map[spec.CandidateKind]int{
+	spec.CandidateBlock:     completionKindClass,
+	spec.CandidateAttribute: completionKindProperty,
+	spec.CandidateValue:     completionKindKeyword,
+	spec.CandidateVariable:  completionKindVariable,
+	spec.CandidateFunction:  completionKindFunction,
+}
+
+// completion returns the completion candidates at the position within the document.
+func (s *Server) completion(doc *document, pos hcl.Pos) *CompletionList {
+	list := &CompletionList{Items: []*CompletionItem{}}
+
+	for _, candidate := range s.spec.Complete(doc.filename, pos) {
+		list.Items = append(list.Items, &CompletionItem{
+			Label:      candidate.Label,
+			Kind:       completionKinds[candidate.Kind],
+			Detail:     candidate.Detail,
+			InsertText: candidate.Insert,
+		})
+	}
+
+	return list
+}
+
+// hover returns information about the variable, function, attribute or block type at the
+// position within the document.
+func (s *Server) hover(doc *document, pos hcl.Pos) *Hover {
+	loc := s.spec.At(doc.filename, pos)
+	if loc == nil {
+		return nil
+	}
+
+	var contents string
+	var rng hcl.Range
+
+	switch {
+	case loc.Traversal != nil:
+		rng = loc.Traversal.SourceRange()
+
+		val, diags := loc.Traversal.TraverseAbs(s.ctx)
+		if diags.HasErrors() {
+			return nil
+		}
+
+		contents = fmt.Sprintf("`%s` (%s)", string(rng.SliceBytes(doc.text)), val.Type().FriendlyName())
+	case isFunctionName(loc.Expression, pos):
+		call := loc.Expression.(*hclsyntax.FunctionCallExpr)
+		rng = call.NameRange
+
+		fn, ok := s.ctx.Functions[call.Name]
+		if !ok {
+			return nil
+		}
+
+		contents = fmt.Sprintf("```\n%s\n```", spec.FunctionSignature(call.Name, fn))
+	case loc.Attribute != nil && containsPos(loc.Attribute.NameRange, pos):
+		rng = loc.Attribute.NameRange
+		contents = fmt.Sprintf("`%s` attribute", loc.AttributeName)
+
+		if loc.Type != cty.NilType {
+			contents = fmt.Sprintf("`%s` attribute (%s)", loc.AttributeName, loc.Type.FriendlyName())
+		}
+
+		contents += s.documentation(strings.Join(loc.SchemaPath, "."))
+	case loc.Block() != nil && containsPos(loc.Block().TypeRange, pos):
+		rng = loc.Block().TypeRange
+		contents = fmt.Sprintf("`%s` block", loc.BlockType)
+
+		if labels := loc.Block().Labels; len(labels) > 0 {
+			contents = fmt.Sprintf("`%s` block labeled `%s`", loc.BlockType, strings.Join(labels, "`, `"))
+		}
+
+		// the schema path of the block ends with a wildcard for each of its labels
+		path := loc.SchemaPath[:len(loc.SchemaPath)-len(loc.Block().Labels)]
+		contents += s.documentation(strings.Join(path, "."))
+	default:
+		return nil
+	}
+
+	hoverRange := newRange(rng)
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: contents},
+		Range:    &hoverRange,
+	}
+}
+
+// documentation returns the Markdown paragraphs documenting the block or attribute at the
+// schema path, if any.
+func (s *Server) documentation(path string) string {
+	doc := s.spec.Documentation(path)
+	if doc == nil {
+		return ""
+	}
+
+	res := ""
+
+	if doc.Deprecation != "" {
+		res += "\n\n**Deprecated:** " + doc.Deprecation
+	}
+
+	if doc.Description != "" {
+		res += "\n\n" + doc.Description
+	}
+
+	if doc.Since != "" {
+		res += fmt.Sprintf("\n\nAdded in %s.", doc.Since)
+	}
+
+	return res
+}
+
+// isFunctionName returns true when the position is on the name of a function call.
+func isFunctionName(expr hcl.Expression, pos hcl.Pos) bool {
+	call, ok := expr.(*hclsyntax.FunctionCallExpr)
+
+	return ok && containsPos(call.NameRange, pos)
+}
+
+// containsPos returns true when the position is within the range, including its end.
+func containsPos(rng hcl.Range, pos hcl.Pos) bool {
+	return pos.Byte >= rng.Start.Byte && pos.Byte <= rng.End.Byte
+}
+
+// definition returns the location of the definition of the variable referenced at the position
+// within the document.
+func (s *Server) definition(doc *document, pos hcl.Pos) *Location {
+	loc := s.spec.At(doc.filename, pos)
+	if loc == nil || loc.Traversal == nil {
+		return nil
+	}
+
+	def := s.spec.Definition(loc.Traversal)
+	if def == nil {
+		return nil
+	}
+
+	return s.location(def.Range)
+}
+
+// references returns the locations of all references to the definition at the position within
+// the document, optionally including the definition itself.
+func (s *Server) references(doc *document, pos hcl.Pos, includeDeclaration bool) []*Location {
+	res := []*Location{}
+
+	def := s.spec.DefinitionAt(doc.filename, pos)
+	if def == nil {
+		return res
+	}
+
+	if includeDeclaration {
+		if loc := s.location(def.Range); loc != nil {
+			res = append(res, loc)
+		}
+	}
+
+	for _, traversal := range s.spec.References(def) {
+		if loc := s.location(traversal.SourceRange()); loc != nil {
+			res = append(res, loc)
+		}
+	}
+
+	return res
+}
+
+// rename replies with the edits renaming the block label or reference at the position. Each
+// changed document is replaced as a whole.
+func (s *Server) rename(msg *message) error {
+	params := &renameParams{}
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return s.conn.replyError(msg.ID, &responseError{Code: codeInvalidParams, Message: err.Error()})
+	}
+
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return s.conn.reply(msg.ID, nil)
+	}
+
+	pos := hcl.Pos{
+		Line:   params.Position.Line + 1,
+		Column: params.Position.Character + 1,
+		Byte:   doc.offset(params.Position),
+	}
+
+	changes, diags := s.spec.Rename(doc.filename, pos, params.NewName)
+	if changes == nil {
+		message := renameFailed
+		if diags.Len() > 0 {
+			message = diags.Raw()[0].Detail
+		}
+
+		return s.conn.replyError(msg.ID, &responseError{Code: codeRequestFailed, Message: message})
+	}
+
+	edit := &WorkspaceEdit{Changes: map[string][]*TextEdit{}}
+
+	for _, doc := range s.sortedDocuments() {
+		if src := changes.Bytes(doc.filename); src != nil {
+			edit.Changes[doc.uri] = []*TextEdit{{Range: doc.fullRange(), NewText: string(src)}}
+		}
+	}
+
+	return s.conn.reply(msg.ID, edit)
+}
+
+// location converts the range to a Location within an open document.
+func (s *Server) location(rng hcl.Range) *Location {
+	for _, doc := range s.sortedDocuments() {
+		if doc.filename == rng.Filename {
+			return &Location{URI: doc.uri, Range: newRange(rng)}
+		}
+	}
+
+	return nil
+}
+
+// newDocument creates a document with the given uri and text.
+func newDocument(uri, text string) *document {
+	filename := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		filename = u.Path
+	}
+
+	return &document{
+		uri:      uri,
+		filename: filename,
+		text:     []byte(text),
+	}
+}
+
+// offset returns the byte offset of the position within the document. Characters are counted
+// as runes which matches UTF-16 code units for all characters within the basic multilingual
+// plane.
+func (d *document) offset(pos Position) int {
+	line := 0
+	char := 0
+
+	for i, r := range string(d.text) {
+		if line == pos.Line && (char == pos.Character || r == '\n') {
+			return i
+		}
+
+		if r == '\n' {
+			line++
+			char = 0
+
+			continue
+		}
+
+		if line == pos.Line {
+			char++
+		}
+	}
+
+	return len(d.text)
+}
+
+// fullRange returns the range of the entire document.
+func (d *document) fullRange() Range {
+	end := Position{}
+
+	for _, r := range string(d.text) {
+		if r == '\n' {
+			end.Line++
+			end.Character = 0
+
+			continue
+		}
+
+		end.Character++
+	}
+
+	return Range{End: end}
+}
+
+// newDiagnostic converts a hcl.Diagnostic to a Diagnostic.
+func newDiagnostic(diag *hcl.Diagnostic) *Diagnostic {
+	res := &Diagnostic{
+		Severity: severityError,
+		Code:     string(spec.CodeOf(diag)),
+		Source:   diagnosticSource,
+		Message:  diag.Summary,
+	}
+
+	if diag.Detail != "" {
+		res.Message = diag.Summary + ": " + diag.Detail
+	}
+
+	if diag.Severity == hcl.DiagWarning {
+		res.Severity = severityWarning
+	}
+
+	if diag.Subject != nil {
+		res.Range = newRange(*diag.Subject)
+	}
+
+	return res
+}
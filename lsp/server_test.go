@@ -0,0 +1,297 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type stationDef struct{}
+
+func (d *stationDef) Name() string {
+	return "station"
+}
+
+func (d *stationDef) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "station",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"name": &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+		},
+	}
+}
+
+func (d *stationDef) Metadata() *parser.Metadata {
+	return &parser.Metadata{
+		Attributes: map[string]*parser.AttributeMetadata{
+			"station.*.name": {Description: "The display name of the station.", Since: "1.1"},
+		},
+	}
+}
+
+func (d *stationDef) Variables(v cty.Value) parser.InjectableVariables {
+	return parser.InjectableVariables{"station": v}
+}
+
+var testDefs = parser.NamedBlockDefinitions{&stationDef{}}
+
+const testDocument = `station "st12" {
+  name = "Station 12"
+}
+
+station "st13" {
+}
+
+unit = station.st12
+`
+
+// generalDef reports a diagnostic without a subject whenever it is parsed.
+type generalDef struct {
+	stationDef
+}
+
+func (d *generalDef) Rules() []*parser.Rule {
+	return []*parser.Rule{
+		{
+			Check: func(block *parser.RuleBlock) hcl.Diagnostics {
+				return hcl.Diagnostics{{Severity: hcl.DiagWarning, Summary: "Configuration incomplete"}}
+			},
+		},
+	}
+}
+
+// session runs the server against the requests and returns all messages it wrote.
+func session(t *testing.T, requests ...*message) []*message {
+	return sessionWith(t, testDefs, requests...)
+}
+
+// sessionWith runs a server for the definitions against the requests and returns all messages
+// it wrote.
+func sessionWith(t *testing.T, defs parser.NamedBlockDefinitions, requests ...*message) []*message {
+	in := new(bytes.Buffer)
+	out := new(bytes.Buffer)
+
+	requestConn := newConn(nil, in)
+	for _, req := range requests {
+		assert.NoError(t, requestConn.write(req))
+	}
+
+	assert.NoError(t, NewServer(defs).Serve(in, out))
+
+	res := []*message{}
+	responseConn := newConn(out, nil)
+
+	for {
+		msg, err := responseConn.read()
+		if err == io.EOF {
+			return res
+		}
+
+		assert.NoError(t, err)
+		res = append(res, msg)
+	}
+}
+
+func request(id int, method string, params interface{}) *message {
+	rawID := json.RawMessage(fmt.Sprint(id))
+	rawParams, _ := json.Marshal(params)
+
+	return &message{ID: &rawID, Method: method, Params: rawParams}
+}
+
+func notification(method string, params interface{}) *message {
+	rawParams, _ := json.Marshal(params)
+
+	return &message{Method: method, Params: rawParams}
+}
+
+func openDocument() *message {
+	return notification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":  "file:///etc/response/stations.hcl",
+			"text": testDocument,
+		},
+	})
+}
+
+func position(id int, method string, line, character int) *message {
+	return request(id, method, map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///etc/response/stations.hcl"},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	})
+}
+
+func result(t *testing.T, msg *message, v interface{}) {
+	raw, err := json.Marshal(msg.Result)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, v))
+}
+
+func TestServer(tt *testing.T) {
+	tt.Run("initialize advertises the server capabilities", func(t *testing.T) {
+		res := session(t, request(1, "initialize", map[string]interface{}{}))
+
+		assert.Len(t, res, 1)
+
+		init := &initializeResult{}
+		result(t, res[0], init)
+		assert.True(t, init.Capabilities.HoverProvider)
+		assert.Equal(t, textDocumentSyncFull, init.Capabilities.TextDocumentSync)
+	})
+
+	tt.Run("opening a document publishes its diagnostics", func(t *testing.T) {
+		res := session(t, openDocument())
+
+		assert.Len(t, res, 1)
+		assert.Equal(t, "textDocument/publishDiagnostics", res[0].Method)
+
+		params := &publishDiagnosticsParams{}
+		assert.NoError(t, json.Unmarshal(res[0].Params, params))
+		assert.Len(t, params.Diagnostics, 1)
+		assert.Equal(t, 4, params.Diagnostics[0].Range.Start.Line)
+		assert.Equal(t, "SPEC012", params.Diagnostics[0].Code)
+	})
+
+	tt.Run("diagnostics without a subject are published with the updated document", func(t *testing.T) {
+		res := sessionWith(t, parser.NamedBlockDefinitions{&generalDef{}}, openDocument())
+
+		assert.Len(t, res, 1)
+
+		params := &publishDiagnosticsParams{}
+		assert.NoError(t, json.Unmarshal(res[0].Params, params))
+		assert.Equal(t, "file:///etc/response/stations.hcl", params.URI)
+
+		messages := []string{}
+		for _, diag := range params.Diagnostics {
+			messages = append(messages, diag.Message)
+		}
+
+		assert.Contains(t, messages, "Configuration incomplete")
+	})
+
+	tt.Run("completion lists block types and the attributes of variables", func(t *testing.T) {
+		res := session(t, openDocument(), position(2, "textDocument/completion", 7, 15), position(3, "textDocument/completion", 7, 0))
+
+		list := &CompletionList{}
+		result(t, res[1], list)
+
+		labels := []string{}
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+
+		assert.Equal(t, []string{"st12", "st13"}, labels)
+
+		result(t, res[2], list)
+		assert.Equal(t, "station", list.Items[0].Label)
+		assert.Equal(t, "block with labels: id", list.Items[0].Detail)
+	})
+
+	tt.Run("hover describes variables, attributes and blocks", func(t *testing.T) {
+		res := session(t,
+			openDocument(),
+			position(2, "textDocument/hover", 7, 17),
+			position(3, "textDocument/hover", 1, 3),
+			position(4, "textDocument/hover", 0, 2),
+		)
+
+		hover := &Hover{}
+		result(t, res[1], hover)
+		assert.Equal(t, "`station.st12` (object)", hover.Contents.Value)
+
+		result(t, res[2], hover)
+		assert.Equal(t, "`name` attribute (string)\n\nThe display name of the station.\n\nAdded in 1.1.", hover.Contents.Value)
+
+		result(t, res[3], hover)
+		assert.Equal(t, "`station` block labeled `st12`", hover.Contents.Value)
+	})
+
+	tt.Run("definition resolves variables to the block defining them", func(t *testing.T) {
+		res := session(t, openDocument(), position(2, "textDocument/definition", 7, 17))
+
+		loc := &Location{}
+		result(t, res[1], loc)
+		assert.Equal(t, "file:///etc/response/stations.hcl", loc.URI)
+		assert.Equal(t, 0, loc.Range.Start.Line)
+		assert.Equal(t, 2, loc.Range.End.Line)
+	})
+
+	tt.Run("references lists the references to a definition", func(t *testing.T) {
+		req := request(2, "textDocument/references", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file:///etc/response/stations.hcl"},
+			"position":     map[string]interface{}{"line": 0, "character": 2},
+			"context":      map[string]interface{}{"includeDeclaration": true},
+		})
+
+		res := session(t, openDocument(), req)
+
+		locs := []*Location{}
+		result(t, res[1], &locs)
+		assert.Len(t, locs, 2)
+		assert.Equal(t, 0, locs[0].Range.Start.Line)
+		assert.Equal(t, 7, locs[1].Range.Start.Line)
+		assert.Equal(t, 7, locs[1].Range.Start.Character)
+	})
+
+	tt.Run("rename replaces documents containing the renamed label", func(t *testing.T) {
+		rename := func(id int, name string) *message {
+			return request(id, "textDocument/rename", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": "file:///etc/response/stations.hcl"},
+				"position":     map[string]interface{}{"line": 0, "character": 10},
+				"newName":      name,
+			})
+		}
+
+		res := session(t, openDocument(), rename(2, "st99"), rename(3, "not valid"))
+
+		edit := &WorkspaceEdit{}
+		result(t, res[1], edit)
+
+		edits := edit.Changes["file:///etc/response/stations.hcl"]
+		assert.Len(t, edits, 1)
+		assert.Equal(t, Range{End: Position{Line: 8}}, edits[0].Range)
+		assert.Contains(t, edits[0].NewText, `station "st99" {`)
+		assert.Contains(t, edits[0].NewText, "unit = station.st99\n")
+
+		assert.Equal(t, codeRequestFailed, res[2].Error.Code)
+	})
+
+	tt.Run("rename fails without diagnostics when they are suppressed", func(t *testing.T) {
+		open := notification("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":  "file:///etc/response/stations.hcl",
+				"text": "station \"st12\" {\n  # spec:ignore SPEC004\n  name = \"Station 12\"\n}\n",
+			},
+		})
+
+		res := session(t, open, request(2, "textDocument/rename", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file:///etc/response/stations.hcl"},
+			"position":     map[string]interface{}{"line": 2, "character": 3},
+			"newName":      "st99",
+		}))
+
+		assert.Equal(t, codeRequestFailed, res[1].Error.Code)
+		assert.Equal(t, renameFailed, res[1].Error.Message)
+	})
+
+	tt.Run("unsupported requests return an error", func(t *testing.T) {
+		res := session(t, request(1, "workspace/symbol", map[string]interface{}{}))
+
+		assert.Equal(t, codeMethodNotFound, res[0].Error.Code)
+	})
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// markdownMaxHeading is the deepest heading level used for nested blocks.
+const markdownMaxHeading = 6
+
+// WriteMarkdown writes reference documentation for the registered BlockDefinition's, in the
+// order they are parsed, as Markdown to the provided io.Writer. Each registration is rendered
+// with its attributes, their types and whether they are required, its nested blocks and the
+// variables and functions it injects. Descriptions, examples, versions and deprecation notices
+// are taken from the parser.Metadata of definitions implementing DescribedBlockDefinition and
+// the injections from those implementing parser.InjectionDescriber.
+func (s *Spec) WriteMarkdown(to io.Writer, title string) error {
+	w := bufio.NewWriter(to)
+
+	if title != "" {
+		fmt.Fprintf(w, "# %s\n", title)
+	}
+
+	for _, reg := range s.orderedRegistrations() {
+		fmt.Fprintf(w, "\n## `%s`\n", reg.BlockName)
+
+		meta := reg.Metadata()
+		if text := markdownDocumentation(meta.Description, meta.Since, meta.Deprecation); text != "" {
+			fmt.Fprintf(w, "\n%s\n", text)
+		}
+
+		spec := reg.Definition.Spec()
+
+		writeMarkdownInjections(w, reg)
+
+		for _, example := range meta.Examples {
+			fmt.Fprintf(w, "\n```hcl\n%s\n```\n", strings.TrimSpace(example))
+		}
+
+		writeMarkdownBody(w, parser.Introspect(spec), meta, 3)
+	}
+
+	return w.Flush()
+}
+
+// orderedRegistrations returns the registrations in the order they are parsed.
+func (s *Spec) orderedRegistrations() []*parser.Registration {
+	res := append([]*parser.Registration{}, s.Registrations()...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Order < res[j].Order
+	})
+
+	return res
+}
+
+// writeMarkdownBody writes the attributes and nested blocks of the body. A description column is
+// only included when at least one of the attributes is documented.
+func writeMarkdownBody(w io.Writer, body *parser.SchemaBody, meta *parser.Metadata, level int) {
+	if len(body.Attributes) > 0 {
+		docs := map[string]string{}
+		for name, attr := range body.Attributes {
+			if doc := meta.Attribute(attr.Path); doc != nil {
+				docs[name] = markdownDocumentation(doc.Description, doc.Since, doc.Deprecation)
+			}
+		}
+
+		if len(docs) > 0 {
+			fmt.Fprintf(w, "\n| Attribute | Type | Required | Description |\n| --- | --- | --- | --- |\n")
+		} else {
+			fmt.Fprintf(w, "\n| Attribute | Type | Required |\n| --- | --- | --- |\n")
+		}
+
+		for _, name := range body.AttributeNames() {
+			attr := body.Attributes[name]
+			fmt.Fprintf(w, "| `%s` | %s | %s |", name, markdownType(attr.Type), yesNo(attr.Required))
+
+			if len(docs) > 0 {
+				fmt.Fprintf(w, " %s |", markdownCell(docs[name]))
+			}
+
+			fmt.Fprintln(w)
+		}
+	}
+
+	for _, name := range body.BlockTypes() {
+		block := body.Blocks[name]
+		if level > markdownMaxHeading {
+			level = markdownMaxHeading
+		}
+
+		fmt.Fprintf(w, "\n%s `%s` block\n\n", strings.Repeat("#", level), block.Path)
+		fmt.Fprintf(w, "%s\n", markdownBlockSummary(block))
+
+		if doc := meta.Attribute(block.Path); doc != nil {
+			if text := markdownDocumentation(doc.Description, doc.Since, doc.Deprecation); text != "" {
+				fmt.Fprintf(w, "\n%s\n", text)
+			}
+		}
+
+		if block.Body != nil {
+			writeMarkdownBody(w, block.Body, meta, level+1)
+		}
+	}
+}
+
+// writeMarkdownInjections writes the variables and functions injected by the registration.
+func writeMarkdownInjections(w io.Writer, reg *parser.Registration) {
+	vars, funcs := injectedNames(reg.Definition)
+
+	if len(vars) > 0 {
+		fmt.Fprintf(w, "\nInjects variables: %s\n", markdownCodeList(vars))
+	}
+
+	if len(funcs) > 0 {
+		fmt.Fprintf(w, "\nInjects functions: %s\n", markdownCodeList(funcs))
+	}
+}
+
+// injectedNames returns the names of the variables and the signatures of the functions listed
+// by a definition implementing parser.InjectionDescriber.
+func injectedNames(def parser.BlockDefinition) (vars []string, funcs []string) {
+	desc, ok := def.(parser.InjectionDescriber)
+	if !ok {
+		return nil, nil
+	}
+
+	vars = append(vars, desc.InjectedVariables()...)
+
+	for name, fn := range desc.InjectedFunctions() {
+		funcs = append(funcs, FunctionSignature(name, fn))
+	}
+
+	sort.Strings(vars)
+	sort.Strings(funcs)
+
+	return vars, funcs
+}
+
+// markdownBlockSummary describes how the block may be repeated and its labels.
+func markdownBlockSummary(block *parser.SchemaBlock) string {
+	switch block.Nesting {
+	case parser.NestingSingle:
+		if block.Required {
+			return "A single block, required."
+		}
+
+		return "A single block, optional."
+	case parser.NestingList, parser.NestingTuple:
+		return "Any number of blocks" + markdownItems(block.MinItems, block.MaxItems) + "."
+	case parser.NestingSet:
+		return "Any number of unique blocks" + markdownItems(block.MinItems, block.MaxItems) + "."
+	case parser.NestingMap, parser.NestingObject:
+		return fmt.Sprintf("Any number of blocks labeled by %s.", markdownCodeList(block.LabelNames))
+	case parser.NestingAttrs:
+		summary := fmt.Sprintf("A single block of arbitrary attributes of type %s", markdownType(block.ElementType))
+		if block.Required {
+			return summary + ", required."
+		}
+
+		return summary + ", optional."
+	default:
+		return ""
+	}
+}
+
+// markdownDocumentation joins the description with the version it was added in and the
+// deprecation notice, if any.
+func markdownDocumentation(description, since, deprecation string) string {
+	parts := []string{}
+
+	if deprecation != "" {
+		parts = append(parts, "**Deprecated:** "+deprecation)
+	}
+
+	if description != "" {
+		parts = append(parts, description)
+	}
+
+	if since != "" {
+		parts = append(parts, fmt.Sprintf("Added in %s.", since))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// markdownCell escapes the text for use within a table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func markdownItems(minItems, maxItems int) string {
+	switch {
+	case minItems > 0 && maxItems > 0:
+		return fmt.Sprintf(", between %d and %d", minItems, maxItems)
+	case minItems > 0:
+		return fmt.Sprintf(", at least %d", minItems)
+	case maxItems > 0:
+		return fmt.Sprintf(", at most %d", maxItems)
+	default:
+		return ""
+	}
+}
+
+func markdownType(ty cty.Type) string {
+	if ty == cty.NilType {
+		return "any"
+	}
+
+	return "`" + ty.FriendlyName() + "`"
+}
+
+func markdownCodeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+type helpersSchema struct{}
+
+func (s *helpersSchema) Name() string {
+	return "helpers"
+}
+
+func (s *helpersSchema) Metadata() *parser.Metadata {
+	return &parser.Metadata{
+		Description: "Helpers available to all blocks.",
+		Since:       "1.2",
+		Examples:    []string{"prefix = \"st\"\n"},
+		Attributes: map[string]*parser.AttributeMetadata{
+			"prefix": {Description: "Prefix of | generated names.", Deprecation: "Use a station name instead."},
+		},
+	}
+}
+
+func (s *helpersSchema) Spec() hcldec.Spec {
+	return &hcldec.AttrSpec{Name: "prefix", Type: cty.String}
+}
+
+func (s *helpersSchema) Functions(v cty.Value) parser.InjectableFunctions {
+	return parser.InjectableFunctions{"upper": stdlib.UpperFunc}
+}
+
+func (s *helpersSchema) InjectedVariables() []string {
+	return nil
+}
+
+func (s *helpersSchema) InjectedFunctions() parser.InjectableFunctions {
+	return s.Functions(cty.NilVal)
+}
+
+type prefixSchema struct{}
+
+func (s *prefixSchema) Name() string {
+	return "prefix"
+}
+
+func (s *prefixSchema) Spec() hcldec.Spec {
+	return &hcldec.AttrSpec{Name: "prefix", Type: cty.String}
+}
+
+func (s *prefixSchema) Variables(v cty.Value) parser.InjectableVariables {
+	return parser.InjectableVariables{"prefix": cty.StringVal(v.AsString())}
+}
+
+func TestWriteMarkdown(tt *testing.T) {
+	tt.Run("WriteMarkdown() documents each registration in order", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, spec.NewSubset(&stationSchema{}, &settingsSchema{}).WriteMarkdown(b, "Reference"))
+
+		out := b.String()
+
+		assert.True(t, strings.HasPrefix(out, "# Reference\n\n## `station`\n\nInjects variables: `station`\n"))
+		assert.True(t, strings.Index(out, "## `station`") < strings.Index(out, "## `settings`"))
+		assert.Contains(t, out, "### `station` block\n\nAny number of blocks labeled by `id`.\n")
+		assert.Contains(t, out, "| `name` | `string` | yes |\n")
+		assert.Contains(t, out, "#### `station.*.unit` block\n\nAny number of blocks.\n")
+		assert.Contains(t, out, "### `settings` block\n\nA single block, required.\n")
+		assert.Contains(t, out, "| `tags` | `list of string` | no |\n")
+		assert.Contains(t, out, "A single block of arbitrary attributes of type `string`, optional.")
+	})
+
+	tt.Run("WriteMarkdown() includes metadata and injected functions", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, spec.NewSubset(&helpersSchema{}).WriteMarkdown(b, ""))
+
+		out := b.String()
+
+		assert.True(t, strings.HasPrefix(out, "\n## `helpers`\n\nHelpers available to all blocks. Added in 1.2.\n"))
+		assert.Contains(t, out, "\n```hcl\nprefix = \"st\"\n```\n")
+		assert.Contains(t, out, "| Attribute | Type | Required | Description |\n")
+		assert.Contains(t, out, "| `prefix` | `string` | no | **Deprecated:** Use a station name instead. Prefix of \\| generated names. |\n")
+		assert.Contains(t, out, "\nInjects functions: `upper(str string)`\n")
+		assert.NotContains(t, out, "Injects variables")
+	})
+
+	tt.Run("WriteMarkdown() omits the injections of undescribed injectors", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, spec.NewSubset(&prefixSchema{}).WriteMarkdown(b, ""))
+
+		assert.NotContains(t, b.String(), "Injects")
+	})
+}
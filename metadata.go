@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+)
+
+// diagnostic messages
+const (
+	DiagDeprecatedAttribute = "Deprecated attribute"
+	DiagDeprecatedBlock     = "Deprecated block"
+	DiagDeprecatedDetail    = "The %s %q is deprecated. %s"
+)
+
+// Documentation returns the documentation of the block or attribute at the schema path, in the
+// form used by parser.SchemaBody.Lookup such as "station.*.unit.callsign". Root blocks and
+// attributes without their own parser.AttributeMetadata are documented by the parser.Metadata of
+// their registration. Nil is returned when there is no documentation.
+func (s *Spec) Documentation(path string) *parser.AttributeMetadata {
+	if path == "" {
+		return nil
+	}
+
+	root := strings.SplitN(path, ".", 2)[0]
+
+	reg := s.registrationFor(root)
+	if reg == nil {
+		return nil
+	}
+
+	meta := reg.Metadata()
+	if attr := meta.Attribute(path); attr != nil {
+		return attr
+	}
+
+	if path == root && (meta.Description != "" || meta.Since != "" || meta.Deprecation != "") {
+		return &parser.AttributeMetadata{
+			Description: meta.Description,
+			Since:       meta.Since,
+			Deprecation: meta.Deprecation,
+		}
+	}
+
+	return nil
+}
+
+// registrationFor returns the registration describing the root block or attribute.
+func (s *Spec) registrationFor(name string) *parser.Registration {
+	for _, reg := range s.orderedRegistrations() {
+		schema := reg.Schema()
+		if schema.Attributes[name] != nil || schema.Blocks[name] != nil {
+			return reg
+		}
+	}
+
+	return nil
+}
+
+// deprecations returns a warning for each deprecated block or attribute within the parsed files.
+func (s *Spec) deprecations() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	schema := parser.Introspect(s.Build())
+
+	for _, filename := range s.filenames() {
+		diags = diags.Extend(s.bodyDeprecations(s.migratedFile(filename).Body, schema))
+	}
+
+	return diags
+}
+
+func (s *Spec) bodyDeprecations(body hcl.Body, schema *parser.SchemaBody) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	content, _, _ := body.PartialContent(hcldec.ImpliedSchema(schema.Spec))
+	if content == nil {
+		return diags
+	}
+
+	for name, attr := range content.Attributes {
+		sa := schema.Attributes[name]
+		if sa == nil {
+			continue
+		}
+
+		if doc := s.Documentation(sa.Path); doc != nil && doc.Deprecation != "" {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  DiagDeprecatedAttribute,
+				Detail:   fmt.Sprintf(DiagDeprecatedDetail, "attribute", name, doc.Deprecation),
+				Subject:  attr.NameRange.Ptr(),
+			})
+		}
+	}
+
+	for _, block := range content.Blocks {
+		sb := schema.Blocks[block.Type]
+		if sb == nil {
+			continue
+		}
+
+		if doc := s.Documentation(sb.Path); doc != nil && doc.Deprecation != "" {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  DiagDeprecatedBlock,
+				Detail:   fmt.Sprintf(DiagDeprecatedDetail, "block", block.Type, doc.Deprecation),
+				Subject:  block.TypeRange.Ptr(),
+			})
+		}
+
+		if sb.Body != nil {
+			diags = diags.Extend(s.bodyDeprecations(block.Body, sb.Body))
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type radioSchema struct{}
+
+func (s *radioSchema) Name() string {
+	return "radio"
+}
+
+func (s *radioSchema) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "radio",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"channel":   &hcldec.AttrSpec{Name: "channel", Type: cty.Number},
+			"frequency": &hcldec.AttrSpec{Name: "frequency", Type: cty.Number},
+			"talkgroup": &hcldec.BlockListSpec{
+				TypeName: "talkgroup",
+				Nested: hcldec.ObjectSpec{
+					"name": &hcldec.AttrSpec{Name: "name", Type: cty.String},
+				},
+			},
+		},
+	}
+}
+
+func (s *radioSchema) Metadata() *parser.Metadata {
+	return &parser.Metadata{
+		Description: "Radios assigned to stations.",
+		Since:       "1.3",
+		Attributes: map[string]*parser.AttributeMetadata{
+			"radio.*.channel":   {Deprecation: "Use frequency instead."},
+			"radio.*.frequency": {Description: "The frequency in MHz."},
+			"radio.*.talkgroup": {Description: "A talkgroup.", Deprecation: "Talkgroups are no longer supported."},
+		},
+	}
+}
+
+const radioSource = `radio "r1" {
+  channel   = 4
+  frequency = 154.28
+
+  talkgroup {
+    name = "fire"
+  }
+}
+`
+
+func TestDocumentation(tt *testing.T) {
+	s := spec.NewSubset(&stationSchema{}, &radioSchema{})
+
+	tt.Run("Documentation() falls back to the registration for root blocks", func(t *testing.T) {
+		doc := s.Documentation("radio")
+		if assert.NotNil(t, doc) {
+			assert.Equal(t, "Radios assigned to stations.", doc.Description)
+			assert.Equal(t, "1.3", doc.Since)
+		}
+	})
+
+	tt.Run("Documentation() returns the metadata of nested attributes", func(t *testing.T) {
+		doc := s.Documentation("radio.*.frequency")
+		if assert.NotNil(t, doc) {
+			assert.Equal(t, "The frequency in MHz.", doc.Description)
+		}
+	})
+
+	tt.Run("Documentation() returns nil when there is no documentation", func(t *testing.T) {
+		assert.Nil(t, s.Documentation(""))
+		assert.Nil(t, s.Documentation("station"))
+		assert.Nil(t, s.Documentation("station.*.name"))
+		assert.Nil(t, s.Documentation("radio.*.talkgroup.name"))
+		assert.Nil(t, s.Documentation("radio.frequency"))
+		assert.Nil(t, s.Documentation("missing"))
+	})
+
+	tt.Run("At() includes the schema path", func(t *testing.T) {
+		s := spec.NewSubset(&radioSchema{})
+		s.ParseSource([]byte(radioSource), "radio.hcl")
+
+		loc := s.At("radio.hcl", hcl.Pos{Line: 6, Column: 7})
+		assert.Equal(t, []string{"radio", "r1", "talkgroup", "name"}, loc.Path)
+		assert.Equal(t, []string{"radio", "*", "talkgroup", "name"}, loc.SchemaPath)
+	})
+}
+
+func TestDeprecations(tt *testing.T) {
+	tt.Run("Parse() warns about deprecated blocks and attributes", func(t *testing.T) {
+		s := spec.NewSubset(&radioSchema{})
+		s.ParseSource([]byte(radioSource), "radio.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+		assert.False(t, diags.HasErrors())
+
+		codes := []spec.Code{}
+		lines := []int{}
+
+		for _, diag := range diags.Diags {
+			assert.Equal(t, hcl.DiagWarning, diag.Severity)
+			codes = append(codes, spec.CodeOf(diag))
+			lines = append(lines, diag.Subject.Start.Line)
+		}
+
+		assert.Equal(t, []spec.Code{spec.CodeDeprecatedAttribute, spec.CodeDeprecatedBlock}, codes)
+		assert.Equal(t, []int{2, 5}, lines)
+		assert.Contains(t, diags.Diags[0].Detail, "Use frequency instead.")
+	})
+
+	tt.Run("JSONSchema() includes descriptions and deprecations", func(t *testing.T) {
+		schema := spec.NewSubset(&radioSchema{}).JSONSchema()
+
+		radio := schema.Properties["radio"]
+		assert.Equal(t, "Radios assigned to stations.", radio.Description)
+
+		body := radio.AdditionalProperties.(*spec.JSONSchema)
+		assert.Equal(t, "Deprecated: Use frequency instead.", body.Properties["channel"].Description)
+		assert.Equal(t, "The frequency in MHz.", body.Properties["frequency"].Description)
+		assert.True(t, strings.HasPrefix(body.Properties["talkgroup"].Description, "Deprecated:"))
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, schema.WriteJSON(b))
+		assert.NotContains(t, b.String(), `"deprecated"`)
+	})
+}
@@ -0,0 +1,743 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// VersionAttribute is the root attribute declaring the version of the schema a file was written
+// for, such as "spec_version = 3".
+const VersionAttribute = "spec_version"
+
+// diagnostic messages
+const (
+	DiagUnsupportedVersion        = "Unsupported spec version"
+	DiagUnsupportedVersionInvalid = "The " + VersionAttribute + " must be a whole number of at least 1."
+	DiagUnsupportedVersionNewer   = "The file declares " + VersionAttribute + " %d but only versions up to %d are supported."
+	DiagCannotMigrate             = "Cannot migrate"
+	DiagCannotMigrateJSON         = "The file %q declares " + VersionAttribute + " %d but JSON files cannot be migrated. Convert it to HCL, or generate it again, for version %d."
+	DiagCannotMigrateStep         = "Migrating %q to version %d failed: %s."
+	DiagMigrated                  = "Configuration migrated"
+	DiagMigratedDetail            = "The file %q declares " + VersionAttribute + " %d and was migrated to version %d before parsing. Use Migrate to update the file."
+)
+
+// Migration rewrites configuration written for the previous version of the schema, Version-1,
+// into the shape expected by Version. The steps are applied in order to the root body of each
+// HCL file declaring an older version.
+type Migration struct {
+	Version     int
+	Description string
+	Steps       []MigrationStep
+}
+
+// MigrationStep rewrites the root body of a HCL file. Steps change the body using hclwrite so
+// the comments and formatting of the file are kept when it is written back.
+type MigrationStep func(body *hclwrite.Body) error
+
+// UseMigrations enables versioning using the chain of migrations, where the current version of
+// the schema is the highest Version of the migrations. Each file may declare the version it was
+// written for using VersionAttribute and files that do not are treated as version 1.
+//
+// Parse migrates older HCL files in memory before parsing them, returning a warning for each,
+// while Migrate returns the migrated files so they can be written back.
+func (s *Spec) UseMigrations(migrations ...*Migration) {
+	s.migrations = append([]*Migration{}, migrations...)
+
+	sort.SliceStable(s.migrations, func(i, j int) bool {
+		return s.migrations[i].Version < s.migrations[j].Version
+	})
+}
+
+// Version returns the current version of the schema. The version is 1 without migrations.
+func (s *Spec) Version() int {
+	if len(s.migrations) == 0 {
+		return 1
+	}
+
+	return s.migrations[len(s.migrations)-1].Version
+}
+
+// versioned returns true when migrations are in use and files may declare their version.
+func (s *Spec) versioned() bool {
+	return len(s.migrations) > 0
+}
+
+// Migrate returns a ChangeSet containing each parsed HCL file declaring an older version,
+// migrated to the current version with VersionAttribute updated. Files without a declared
+// version have VersionAttribute added to the top of the file. Error diagnostics are returned for
+// older JSON files, which cannot be migrated.
+func (s *Spec) Migrate() (*ChangeSet, *Diagnostics) {
+	changes := newChangeSet()
+	diags := hcl.Diagnostics{}
+
+	if !s.versioned() {
+		return changes, s.diagnostics(diags)
+	}
+
+	for _, filename := range s.filenames() {
+		src, fileDiags := s.migrateFile(filename)
+		diags = diags.Extend(fileDiags)
+
+		if src == nil {
+			continue
+		}
+
+		if !declaresVersion(s.files[filename]) {
+			src = append(s.versionHeader(), src...)
+		}
+
+		changes.files[filename] = src
+	}
+
+	return changes, s.diagnostics(diags)
+}
+
+// migrate migrates each parsed file declaring an older version in memory, replacing the files
+// used by Body until the files are parsed again. The migrated files are not given the
+// VersionAttribute and the ranges within them are moved to the lines of the parsed files they
+// were migrated from, so the migrated files may be used in place of the parsed files.
+func (s *Spec) migrate() hcl.Diagnostics {
+	s.migrated = map[string]*hcl.File{}
+	diags := hcl.Diagnostics{}
+
+	if !s.versioned() {
+		return diags
+	}
+
+	for _, filename := range s.filenames() {
+		src, fileDiags := s.migrateFile(filename)
+		diags = diags.Extend(fileDiags)
+
+		if src == nil {
+			continue
+		}
+
+		original := s.files[filename]
+
+		file, parseDiags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+		newLineMap(original.Bytes, src).rebase(reflect.ValueOf([]interface{}{file, parseDiags}))
+
+		if !parseDiags.HasErrors() {
+			s.migrated[filename] = &hcl.File{Body: file.Body, Bytes: original.Bytes, Nav: file.Nav}
+		}
+
+		version, _ := fileVersion(original)
+		diags = diags.Extend(parseDiags).Append(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  DiagMigrated,
+			Detail:   fmt.Sprintf(DiagMigratedDetail, filename, version, s.Version()),
+			Subject:  versionRange(original),
+		})
+	}
+
+	return diags
+}
+
+// migratedFile returns the file migrated by the last call to Parse or, when it was not migrated,
+// the parsed file. Everything reading the configuration of the parsed files uses it, while the
+// parsed files are used for editing them. A migrated file holds the contents of the parsed file
+// as its ranges refer to the parsed file.
+func (s *Spec) migratedFile(filename string) *hcl.File {
+	if file, ok := s.migrated[filename]; ok {
+		return file
+	}
+
+	return s.files[filename]
+}
+
+// migrateFile returns the migrated contents of the file or nil when the file does not need to be
+// migrated. The VersionAttribute is updated when the file declares it but is not added.
+func (s *Spec) migrateFile(filename string) ([]byte, hcl.Diagnostics) {
+	file := s.files[filename]
+
+	version, diags := fileVersion(file)
+	if diags.HasErrors() || version >= s.Version() {
+		return nil, diags.Extend(s.checkVersion(file, version))
+	}
+
+	if !isHCLBody(file.Body) {
+		return nil, diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  DiagCannotMigrate,
+			Detail:   fmt.Sprintf(DiagCannotMigrateJSON, filename, version, s.Version()),
+			Subject:  versionRange(file),
+		})
+	}
+
+	wfile, writeDiags := hclwrite.ParseConfig(file.Bytes, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	if writeDiags.HasErrors() {
+		return nil, diags.Extend(writeDiags)
+	}
+
+	for _, migration := range s.migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		for _, step := range migration.Steps {
+			if err := step(wfile.Body()); err != nil {
+				return nil, diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  DiagCannotMigrate,
+					Detail:   fmt.Sprintf(DiagCannotMigrateStep, filename, migration.Version, err),
+					Subject:  &hcl.Range{Filename: filename},
+				})
+			}
+		}
+	}
+
+	if wfile.Body().GetAttribute(VersionAttribute) != nil {
+		wfile.Body().SetAttributeValue(VersionAttribute, cty.NumberIntVal(int64(s.Version())))
+	}
+
+	return wfile.Bytes(), diags
+}
+
+// checkVersion returns an error when the version is newer than the current version.
+func (s *Spec) checkVersion(file *hcl.File, version int) hcl.Diagnostics {
+	if version <= s.Version() {
+		return nil
+	}
+
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagUnsupportedVersion,
+			Detail:   fmt.Sprintf(DiagUnsupportedVersionNewer, version, s.Version()),
+			Subject:  versionRange(file),
+		},
+	}
+}
+
+// versionHeader returns the declaration of the current version written to the top of new and
+// migrated files, or nil when migrations are not in use.
+func (s *Spec) versionHeader() []byte {
+	if !s.versioned() {
+		return nil
+	}
+
+	return []byte(fmt.Sprintf("%s = %d\n\n", VersionAttribute, s.Version()))
+}
+
+// versionSchema describes the VersionAttribute within the root body.
+var versionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: VersionAttribute}},
+}
+
+// fileVersion returns the version declared by the file, or 1 when it does not declare one.
+func fileVersion(file *hcl.File) (int, hcl.Diagnostics) {
+	content, _, diags := file.Body.PartialContent(versionSchema)
+	if diags.HasErrors() {
+		return 0, diags
+	}
+
+	attr, ok := content.Attributes[VersionAttribute]
+	if !ok {
+		return 1, nil
+	}
+
+	invalid := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagUnsupportedVersion,
+			Detail:   DiagUnsupportedVersionInvalid,
+			Subject:  attr.Expr.Range().Ptr(),
+		},
+	}
+
+	val, valDiags := attr.Expr.Value(nil)
+	if valDiags.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.Number {
+		return 0, invalid
+	}
+
+	var version int
+	if err := gocty.FromCtyValue(val, &version); err != nil || version < 1 {
+		return 0, invalid
+	}
+
+	return version, nil
+}
+
+// declaresVersion returns true when the file declares the VersionAttribute.
+func declaresVersion(file *hcl.File) bool {
+	content, _, _ := file.Body.PartialContent(versionSchema)
+
+	return content.Attributes[VersionAttribute] != nil
+}
+
+// versionRange returns the range of the VersionAttribute within the file, or of the start of
+// the file when it does not declare one.
+func versionRange(file *hcl.File) *hcl.Range {
+	content, _, _ := file.Body.PartialContent(versionSchema)
+	if attr, ok := content.Attributes[VersionAttribute]; ok {
+		return attr.Range.Ptr()
+	}
+
+	rng := file.Body.MissingItemRange()
+
+	return &rng
+}
+
+// lineMap maps the positions within a file migrated in memory to the parsed file it was migrated
+// from.
+type lineMap struct {
+	original []byte
+	migrated []byte
+
+	// lines holds the line of the parsed file for each line of the migrated file, or zero for
+	// lines added by a migration.
+	lines []int
+
+	// visited holds the pointers already rebased as the diagnostics of a file may share the
+	// expressions of its body.
+	visited map[uintptr]bool
+}
+
+func newLineMap(original, migrated []byte) *lineMap {
+	return &lineMap{
+		original: original,
+		migrated: migrated,
+		lines:    matchLines(splitLines(original), splitLines(migrated)),
+		visited:  map[uintptr]bool{},
+	}
+}
+
+// pos returns the position within the parsed file of a position within the migrated file. The
+// position is kept within the text its line shares with the line it was migrated from, and moved
+// to the start of the changed text otherwise. Positions on lines added by a migration are moved to
+// the end of the closest preceding line kept from the parsed file.
+func (m *lineMap) pos(pos hcl.Pos) hcl.Pos {
+	kept := pos.Line
+	offset := pos.Byte - lineStart(m.migrated, pos.Line)
+
+	for kept > len(m.lines) || (kept > 0 && m.lines[kept-1] == 0) {
+		kept--
+		offset = -1
+	}
+
+	if kept < 1 {
+		return hcl.Pos{Line: 1, Column: 1, Byte: 0}
+	}
+
+	line := m.lines[kept-1]
+	start, end := lineOffsets(m.original, line)
+	original := string(m.original[start:end])
+
+	if offset >= 0 {
+		migratedStart, migratedEnd := lineOffsets(m.migrated, kept)
+		migrated := string(m.migrated[migratedStart:migratedEnd])
+
+		prefix, suffix := commonAffixes(original, migrated)
+
+		switch {
+		case offset <= prefix:
+		case offset >= len(migrated)-suffix:
+			offset = len(original) - (len(migrated) - offset)
+		default:
+			offset = prefix
+		}
+	}
+
+	if offset < 0 || offset > len(original) {
+		offset = len(original)
+	}
+
+	return hcl.Pos{Line: line, Column: utf8.RuneCountInString(original[:offset]) + 1, Byte: start + offset}
+}
+
+// rebase rewrites every hcl.Range reachable from the value through exported fields, such as
+// those of a parsed body or its diagnostics, to the positions within the parsed file.
+func (m *lineMap) rebase(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || m.visited[v.Pointer()] {
+			return
+		}
+
+		m.visited[v.Pointer()] = true
+		m.rebase(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr || !v.CanSet() {
+			m.rebase(elem)
+			return
+		}
+
+		// values held by an interface, such as the steps of a traversal, are not addressable so
+		// a copy is rebased and stored in their place
+		rebased := reflect.New(elem.Type()).Elem()
+		rebased.Set(elem)
+		m.rebase(rebased)
+		v.Set(rebased)
+	case reflect.Struct:
+		if v.Type() == rangeType {
+			if v.CanSet() {
+				rng := v.Interface().(hcl.Range)
+				v.Set(reflect.ValueOf(hcl.Range{Filename: rng.Filename, Start: m.pos(rng.Start), End: m.pos(rng.End)}))
+			}
+
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				m.rebase(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			m.rebase(v.Index(i))
+		}
+	case reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			m.rebase(it.Value())
+		}
+	}
+}
+
+var rangeType = reflect.TypeOf(hcl.Range{})
+
+// splitLines returns the lines of the src without their line endings.
+func splitLines(src []byte) []string {
+	return strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+}
+
+// lineStart returns the byte offset of the start of the line, which starts at 1.
+func lineStart(src []byte, line int) int {
+	start, _ := lineOffsets(src, line)
+
+	return start
+}
+
+// lineOffsets returns the byte offsets of the start and end of the line, which starts at 1,
+// excluding the line ending.
+func lineOffsets(src []byte, line int) (int, int) {
+	start := 0
+
+	for i := 1; i < line; i++ {
+		next := bytes.IndexByte(src[start:], '\n')
+		if next < 0 {
+			return len(src), len(src)
+		}
+
+		start += next + 1
+	}
+
+	end := bytes.IndexByte(src[start:], '\n')
+	if end < 0 {
+		end = len(src) - start
+	}
+
+	return start, start + len(bytes.TrimSuffix(src[start:start+end], []byte("\r")))
+}
+
+// matchLineLimit is the largest number of line comparisons made when matching the changed lines
+// of a migrated file. Larger changes leave their lines unmatched.
+const matchLineLimit = 1 << 20
+
+// matchLines returns, for each of the lines of to, the line of from it is kept from or zero when
+// it was added. Unchanged lines are found using the longest common subsequence of the lines
+// between the common prefix and suffix, while changed lines are matched in order when the same
+// number of lines was removed as added, such as when a block or attribute is renamed.
+func matchLines(from, to []string) []int {
+	res := make([]int, len(to))
+
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		res[prefix] = prefix + 1
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		res[len(to)-1-suffix] = len(from) - suffix
+		suffix++
+	}
+
+	a, b := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+
+	// pairs holds the matched lines of a and b, in order, followed by the end of both
+	pairs := [][2]int{}
+
+	if len(a)*len(b) <= matchLineLimit {
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				switch {
+				case a[i] == b[j]:
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				case lcs[i+1][j] >= lcs[i][j+1]:
+					lcs[i][j] = lcs[i+1][j]
+				default:
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		for i, j := 0, 0; i < len(a) && j < len(b); {
+			switch {
+			case a[i] == b[j]:
+				pairs = append(pairs, [2]int{i, j})
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	pairs = append(pairs, [2]int{len(a), len(b)})
+
+	i, j := 0, 0
+	for _, pair := range pairs {
+		if pair[0]-i == pair[1]-j {
+			for k := 0; k < pair[0]-i; k++ {
+				res[prefix+j+k] = prefix + i + k + 1
+			}
+		} else {
+			// lines are matched in order with the similar lines replacing them, such as when an
+			// attribute is renamed next to one that was removed
+			for k, l := i, j; k < pair[0] && l < pair[1]; l++ {
+				for m := k; m < pair[0]; m++ {
+					if similarLines(a[m], b[l]) {
+						res[prefix+l] = prefix + m + 1
+						k = m + 1
+
+						break
+					}
+				}
+			}
+		}
+
+		if pair[0] < len(a) {
+			res[prefix+pair[1]] = prefix + pair[0] + 1
+		}
+
+		i, j = pair[0]+1, pair[1]+1
+	}
+
+	return res
+}
+
+// similarLines returns true when at least half of the longer of the lines is shared with the other
+// at their start and end.
+func similarLines(a, b string) bool {
+	prefix, suffix := commonAffixes(a, b)
+	if len(b) > len(a) {
+		a = b
+	}
+
+	return len(a) > 0 && 2*(prefix+suffix) >= len(a)
+}
+
+// commonAffixes returns the length of the common prefix of the lines and of the common suffix
+// following it.
+func commonAffixes(a, b string) (int, int) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
+
+// RenameAttribute returns a MigrationStep renaming the attribute from one name to another within
+// every block at the path, which contains block types and matches blocks with any labels. An
+// empty path renames the attribute within the root body.
+func RenameAttribute(path []string, from, to string) MigrationStep {
+	return func(body *hclwrite.Body) error {
+		for _, b := range migrationBodies(body, path) {
+			attr := b.GetAttribute(from)
+			if attr == nil {
+				continue
+			}
+
+			if b.GetAttribute(to) != nil {
+				return fmt.Errorf("cannot rename %q as %q is already set", from, to)
+			}
+
+			// the tokens of the attribute are shared with the body so the name is changed in place
+			// keeping its position and comments
+			for _, token := range attr.BuildTokens(nil) {
+				if token.Type == hclsyntax.TokenIdent && string(token.Bytes) == from {
+					token.Bytes = []byte(to)
+					break
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// RenameBlock returns a MigrationStep changing the type of every block at the path, where the
+// last element of the path is the type of the blocks to rename.
+func RenameBlock(path []string, to string) MigrationStep {
+	return func(body *hclwrite.Body) error {
+		if len(path) == 0 {
+			return fmt.Errorf("the path of the blocks to rename is empty")
+		}
+
+		for _, b := range migrationBodies(body, path[:len(path)-1]) {
+			for _, block := range b.Blocks() {
+				if block.Type() == path[len(path)-1] {
+					block.SetType(to)
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// MoveBlocks returns a MigrationStep moving every block at the path to the end of the body of
+// the block at the destination, where both contain block types. An empty destination moves the
+// blocks to the root body. Missing blocks of the destination are added without labels while a
+// destination matching more than one block is an error.
+func MoveBlocks(path []string, to []string) MigrationStep {
+	return func(body *hclwrite.Body) error {
+		if len(path) == 0 {
+			return fmt.Errorf("the path of the blocks to move is empty")
+		}
+
+		moved := []*hclwrite.Block{}
+
+		for _, b := range migrationBodies(body, path[:len(path)-1]) {
+			for _, block := range b.Blocks() {
+				if block.Type() == path[len(path)-1] {
+					b.RemoveBlock(block)
+					moved = append(moved, block)
+				}
+			}
+		}
+
+		if len(moved) == 0 {
+			return nil
+		}
+
+		dest := body
+		for _, typeName := range to {
+			blocks := []*hclwrite.Block{}
+			for _, block := range dest.Blocks() {
+				if block.Type() == typeName {
+					blocks = append(blocks, block)
+				}
+			}
+
+			switch len(blocks) {
+			case 0:
+				dest = appendBlock(dest, typeName, nil).Body()
+			case 1:
+				dest = blocks[0].Body()
+			default:
+				return fmt.Errorf("cannot move blocks into %q as there is more than one", typeName)
+			}
+		}
+
+		for _, block := range moved {
+			if len(dest.Attributes()) > 0 || len(dest.Blocks()) > 0 {
+				dest.AppendNewline()
+			}
+
+			dest.AppendBlock(block)
+		}
+
+		return nil
+	}
+}
+
+// SplitAttribute returns a MigrationStep replacing the attribute within every block at the path
+// with the attributes returned by split, which is given the value of the attribute. Only
+// attributes with a value that can be evaluated without variables or functions can be split.
+func SplitAttribute(path []string, name string, split func(val cty.Value) (map[string]cty.Value, error)) MigrationStep {
+	return func(body *hclwrite.Body) error {
+		for _, b := range migrationBodies(body, path) {
+			attr := b.GetAttribute(name)
+			if attr == nil {
+				continue
+			}
+
+			expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				return diags
+			}
+
+			val, diags := expr.Value(nil)
+			if diags.HasErrors() {
+				return fmt.Errorf("the value of %q must not use variables or functions", name)
+			}
+
+			attrs, err := split(val)
+			if err != nil {
+				return err
+			}
+
+			b.RemoveAttribute(name)
+
+			names := make([]string, 0, len(attrs))
+			for attrName := range attrs {
+				names = append(names, attrName)
+			}
+
+			sort.Strings(names)
+
+			for _, attrName := range names {
+				b.SetAttributeValue(attrName, attrs[attrName])
+			}
+		}
+
+		return nil
+	}
+}
+
+// migrationBodies returns the bodies of every block at the path, matching blocks with any labels.
+func migrationBodies(body *hclwrite.Body, path []string) []*hclwrite.Body {
+	bodies := []*hclwrite.Body{body}
+
+	for _, typeName := range path {
+		next := []*hclwrite.Body{}
+
+		for _, b := range bodies {
+			for _, block := range b.Blocks() {
+				if block.Type() == typeName {
+					next = append(next, block.Body())
+				}
+			}
+		}
+
+		bodies = next
+	}
+
+	return bodies
+}
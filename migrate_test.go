@@ -0,0 +1,206 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+var stationMigrations = []*spec.Migration{
+	{
+		Version:     3,
+		Description: "Replace the radio string with the channel number.",
+		Steps: []spec.MigrationStep{
+			spec.SplitAttribute([]string{"station"}, "radio", func(val cty.Value) (map[string]cty.Value, error) {
+				channel, err := convert.Convert(val, cty.Number)
+				if err != nil {
+					return nil, fmt.Errorf("the radio %s is not a channel number", val.GoString())
+				}
+
+				return map[string]cty.Value{"channel": channel}, nil
+			}),
+		},
+	},
+	{
+		Version:     2,
+		Description: "Rename the station title and apparatus.",
+		Steps: []spec.MigrationStep{
+			spec.RenameAttribute([]string{"station"}, "title", "name"),
+			spec.RenameBlock([]string{"station", "apparatus"}, "unit"),
+		},
+	},
+}
+
+const migrateSource = `# Stations written before versioning.
+station "st12" {
+  title = "Station 12" # the main station
+  radio = "12"
+
+  apparatus {
+    callsign = "M12"
+  }
+}
+`
+
+func TestMigrations(tt *testing.T) {
+	newSpec := func(t *testing.T, src string) *spec.Spec {
+		s := spec.NewSubset(&stationSchema{})
+		s.UseMigrations(stationMigrations...)
+		assert.False(t, s.ParseHCL([]byte(src), "stations.hcl").HasErrors())
+
+		return s
+	}
+
+	tt.Run("Version() is the highest version of the migrations", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		assert.Equal(t, 1, s.Version())
+
+		s.UseMigrations(stationMigrations...)
+		assert.Equal(t, 3, s.Version())
+	})
+
+	tt.Run("Parse() migrates older files in memory", func(t *testing.T) {
+		s := newSpec(t, migrateSource)
+
+		diags := s.Parse(&hcl.EvalContext{})
+		assert.False(t, diags.HasErrors(), diags.Error())
+		assert.Equal(t, []spec.Code{spec.CodeMigrated}, diagnosticCodes(diags))
+
+		val, hclDiags := hcldec.Decode(s.Body(), s.Build(), &hcl.EvalContext{})
+		assert.False(t, hclDiags.HasErrors())
+
+		station := val.GetAttr("station").Index(cty.StringVal("st12"))
+		assert.Equal(t, cty.StringVal("Station 12"), station.GetAttr("name"))
+		assert.True(t, station.GetAttr("channel").RawEquals(cty.NumberIntVal(12)))
+		assert.Equal(t, 1, station.GetAttr("unit").LengthInt())
+	})
+
+	tt.Run("Parse() reports diagnostics of migrated files at their original lines", func(t *testing.T) {
+		s := newSpec(t, "# Stations written before versioning.\nstation \"st12\" {\n  title = [\"Station 12\"]\n  radio = \"12\"\n}\n")
+
+		diags := s.Parse(&hcl.EvalContext{}).Errors()
+		assert.Equal(t, 1, diags.Len(), diags.Error())
+		assert.Equal(t, 3, diags.Raw()[0].Subject.Start.Line)
+		assert.Equal(t, 11, diags.Raw()[0].Subject.Start.Column)
+	})
+
+	tt.Run("At() and DefinitionAt() use the migrated files at their original positions", func(t *testing.T) {
+		s := newSpec(t, migrateSource)
+		assert.False(t, s.Parse(&hcl.EvalContext{}).HasErrors())
+
+		loc := s.At("stations.hcl", hcl.Pos{Line: 3, Column: 4})
+		assert.Equal(t, "name", loc.AttributeName)
+
+		def := s.DefinitionAt("stations.hcl", hcl.Pos{Line: 3, Column: 4})
+		if assert.NotNil(t, def) {
+			assert.Equal(t, []string{"station", "st12", "name"}, def.Path)
+			assert.Equal(t, 3, def.Range.Start.Line)
+		}
+	})
+
+	tt.Run("Parse() accepts files declaring the current version", func(t *testing.T) {
+		s := newSpec(t, "spec_version = 3\n\nstation \"st12\" {\n  name = \"Station 12\"\n}\n")
+
+		s.UseStrict(true)
+		assert.Equal(t, 0, s.Parse(&hcl.EvalContext{}).Len())
+	})
+
+	tt.Run("Parse() rejects invalid and newer versions", func(t *testing.T) {
+		for _, version := range []string{"4", "0", "\"three\"", "2.5"} {
+			s := newSpec(t, "spec_version = "+version+"\n")
+
+			diags := s.Parse(&hcl.EvalContext{})
+			assert.Equal(t, []spec.Code{spec.CodeUnsupportedVersion}, diagnosticCodes(diags), version)
+		}
+	})
+
+	tt.Run("Migrate() returns the migrated files keeping comments", func(t *testing.T) {
+		s := newSpec(t, migrateSource)
+
+		changes, diags := s.Migrate()
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, []string{"stations.hcl"}, changes.Filenames())
+		assert.Equal(t, `spec_version = 3
+
+# Stations written before versioning.
+station "st12" {
+  name = "Station 12" # the main station
+
+  unit {
+    callsign = "M12"
+  }
+  channel = 12
+}
+`, string(changes.Bytes("stations.hcl")))
+	})
+
+	tt.Run("Migrate() only applies the migrations after the declared version", func(t *testing.T) {
+		s := newSpec(t, "spec_version = 2\n\nstation \"st12\" {\n  name  = \"Station 12\"\n  radio = 12\n}\n")
+
+		changes, diags := s.Migrate()
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, "spec_version = 3\n\nstation \"st12\" {\n  name    = \"Station 12\"\n  channel = 12\n}\n", string(changes.Bytes("stations.hcl")))
+
+		s = newSpec(t, "spec_version = 3\n")
+		changes, _ = s.Migrate()
+		assert.Equal(t, 0, changes.Len())
+	})
+
+	tt.Run("Migrate() reports failed steps and JSON files", func(t *testing.T) {
+		s := newSpec(t, "station \"st12\" {\n  title = \"Station 12\"\n  radio = \"twelve\"\n}\n")
+
+		changes, diags := s.Migrate()
+		assert.Equal(t, 0, changes.Len())
+		assert.Equal(t, []spec.Code{spec.CodeCannotMigrate}, diagnosticCodes(diags))
+
+		s = spec.NewSubset(&stationSchema{})
+		s.UseMigrations(stationMigrations...)
+		assert.False(t, s.Files("./testdata/stations/stations.json").HasErrors())
+
+		_, diags = s.Migrate()
+		assert.Equal(t, []spec.Code{spec.CodeCannotMigrate}, diagnosticCodes(diags))
+	})
+
+	tt.Run("MoveBlocks() moves blocks into another block", func(t *testing.T) {
+		file, _ := hclwrite.ParseConfig([]byte("unit {\n  callsign = \"M12\"\n}\n\nstation {\n  name = \"Station 12\"\n}\n"), "", hcl.Pos{Line: 1, Column: 1})
+
+		assert.NoError(t, spec.MoveBlocks([]string{"unit"}, []string{"station", "roster"})(file.Body()))
+		assert.Contains(t, string(hclwrite.Format(file.Bytes())), "station {\n  name = \"Station 12\"\n\n  roster {\n    unit {\n      callsign = \"M12\"\n    }\n  }\n}\n")
+		assert.Len(t, file.Body().Blocks(), 1)
+	})
+
+	tt.Run("Skeleton() and EncodeValue() declare the current version", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.UseMigrations(stationMigrations...)
+
+		src, err := s.Skeleton(spec.SyntaxHCL)
+		assert.NoError(t, err)
+		assert.Contains(t, string(src), "spec_version = 3\n")
+
+		src, diags := s.EncodeValue(encodeValue, spec.SyntaxHCL)
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, "spec_version = 3\n\n"+encodedSource, string(src))
+	})
+}
+
+func diagnosticCodes(diags *spec.Diagnostics) []spec.Code {
+	res := []spec.Code{}
+	for _, diag := range diags.Raw() {
+		res = append(res, spec.CodeOf(diag))
+	}
+
+	return res
+}
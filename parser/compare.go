@@ -0,0 +1,248 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ChangeKind classifies a difference between two versions of a schema.
+type ChangeKind int
+
+// supported kinds of change
+const (
+	// ChangeAdded is an attribute or block that was added.
+	ChangeAdded ChangeKind = iota
+
+	// ChangeRemoved is an attribute or block that was removed.
+	ChangeRemoved
+
+	// ChangeRequired is an attribute or block that was made required, or no longer is.
+	ChangeRequired
+
+	// ChangeType is a change to the type of an attribute or to the element type of a block of
+	// NestingAttrs.
+	ChangeType
+
+	// ChangeDefault is a change to the default value of an attribute.
+	ChangeDefault
+
+	// ChangeLabels is a change to the number or names of the labels of a block.
+	ChangeLabels
+
+	// ChangeNesting is a change to how a block may be repeated.
+	ChangeNesting
+
+	// ChangeLimits is a change to the minimum or maximum number of blocks.
+	ChangeLimits
+)
+
+// String returns the name of the ChangeKind.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeRequired:
+		return "required"
+	case ChangeType:
+		return "type"
+	case ChangeDefault:
+		return "default"
+	case ChangeLabels:
+		return "labels"
+	case ChangeNesting:
+		return "nesting"
+	case ChangeLimits:
+		return "limits"
+	default:
+		return "unknown"
+	}
+}
+
+// SchemaChange is a single difference between two versions of a schema. A change is breaking
+// when configuration that was valid for the old version may be invalid for the new version.
+// Path is the path of the attribute or block as described by SchemaBody.Lookup.
+type SchemaChange struct {
+	Path     string
+	Kind     ChangeKind
+	Breaking bool
+	Message  string
+}
+
+// CompareSchemas returns the differences from an older version of a schema to a newer one,
+// ordered by path. Removed attributes and blocks, attributes and blocks that are newly
+// required, types that no longer accept every value of the old type, changed label counts and
+// tightened nesting or limits are breaking while all other changes are not.
+func CompareSchemas(from, to *SchemaBody) []*SchemaChange {
+	changes := []*SchemaChange{}
+	compareBodies(from, to, &changes)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+func compareBodies(from, to *SchemaBody, changes *[]*SchemaChange) {
+	add := func(path string, kind ChangeKind, breaking bool, format string, args ...interface{}) {
+		*changes = append(*changes, &SchemaChange{
+			Path:     path,
+			Kind:     kind,
+			Breaking: breaking,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, name := range unionNames(from.AttributeNames(), to.AttributeNames()) {
+		oldAttr, newAttr := from.Attributes[name], to.Attributes[name]
+
+		switch {
+		case newAttr == nil:
+			add(oldAttr.Path, ChangeRemoved, true, "The attribute was removed.")
+		case oldAttr == nil && newAttr.Required:
+			add(newAttr.Path, ChangeAdded, true, "The required attribute was added.")
+		case oldAttr == nil:
+			add(newAttr.Path, ChangeAdded, false, "The attribute was added.")
+		default:
+			if oldAttr.Required != newAttr.Required {
+				add(newAttr.Path, ChangeRequired, newAttr.Required, requiredMessage("attribute", newAttr.Required))
+			}
+
+			if !oldAttr.Type.Equals(newAttr.Type) {
+				add(newAttr.Path, ChangeType, !widens(oldAttr.Type, newAttr.Type),
+					"The type changed from %s to %s.", oldAttr.Type.FriendlyName(), newAttr.Type.FriendlyName())
+			}
+
+			if !equalDefaults(oldAttr.Default, newAttr.Default) {
+				add(newAttr.Path, ChangeDefault, false, "The default value changed.")
+			}
+		}
+	}
+
+	for _, name := range unionNames(from.BlockTypes(), to.BlockTypes()) {
+		oldBlock, newBlock := from.Blocks[name], to.Blocks[name]
+
+		switch {
+		case newBlock == nil:
+			add(oldBlock.Path, ChangeRemoved, true, "The block was removed.")
+			continue
+		case oldBlock == nil && newBlock.Required:
+			add(newBlock.Path, ChangeAdded, true, "The required block was added.")
+			continue
+		case oldBlock == nil:
+			add(newBlock.Path, ChangeAdded, false, "The block was added.")
+			continue
+		}
+
+		path := newBlock.Path
+
+		if len(oldBlock.LabelNames) != len(newBlock.LabelNames) {
+			add(path, ChangeLabels, true, "The number of labels changed from %d to %d.", len(oldBlock.LabelNames), len(newBlock.LabelNames))
+		} else if strings.Join(oldBlock.LabelNames, ",") != strings.Join(newBlock.LabelNames, ",") {
+			add(path, ChangeLabels, false, "The labels were renamed from %s to %s.",
+				strings.Join(oldBlock.LabelNames, ", "), strings.Join(newBlock.LabelNames, ", "))
+		}
+
+		if oldBlock.Nesting != newBlock.Nesting {
+			add(path, ChangeNesting, !loosensNesting(oldBlock.Nesting, newBlock.Nesting),
+				"The nesting mode changed from %s to %s.", oldBlock.Nesting, newBlock.Nesting)
+		}
+
+		if oldBlock.Required != newBlock.Required {
+			add(path, ChangeRequired, newBlock.Required, requiredMessage("block", newBlock.Required))
+		}
+
+		if oldBlock.MinItems != newBlock.MinItems {
+			add(path, ChangeLimits, newBlock.MinItems > oldBlock.MinItems,
+				"The minimum number of blocks changed from %d to %d.", oldBlock.MinItems, newBlock.MinItems)
+		}
+
+		if oldBlock.MaxItems != newBlock.MaxItems {
+			add(path, ChangeLimits, newBlock.MaxItems != 0 && (oldBlock.MaxItems == 0 || newBlock.MaxItems < oldBlock.MaxItems),
+				"The maximum number of blocks changed from %s to %s.", maxItems(oldBlock.MaxItems), maxItems(newBlock.MaxItems))
+		}
+
+		if oldBlock.ElementType != cty.NilType && newBlock.ElementType != cty.NilType && !oldBlock.ElementType.Equals(newBlock.ElementType) {
+			add(path, ChangeType, !widens(oldBlock.ElementType, newBlock.ElementType),
+				"The element type changed from %s to %s.", oldBlock.ElementType.FriendlyName(), newBlock.ElementType.FriendlyName())
+		}
+
+		if oldBlock.Body != nil && newBlock.Body != nil {
+			compareBodies(oldBlock.Body, newBlock.Body, changes)
+		}
+	}
+}
+
+// widens returns true when every value of the from type can be converted to the to type.
+func widens(from, to cty.Type) bool {
+	return convert.GetConversion(from, to) != nil
+}
+
+// loosensNesting returns true when every block valid for the from nesting mode is valid for the
+// to nesting mode. Blocks written once may be repeated by a list, set or tuple, while labeled
+// blocks are written the same way whether they are decoded as a map or an object.
+func loosensNesting(from, to NestingMode) bool {
+	switch from {
+	case NestingSingle, NestingList, NestingSet, NestingTuple:
+		return to == NestingList || to == NestingSet || to == NestingTuple
+	case NestingMap, NestingObject:
+		return to == NestingMap || to == NestingObject
+	default:
+		return false
+	}
+}
+
+func requiredMessage(kind string, required bool) string {
+	if required {
+		return fmt.Sprintf("The %s is now required.", kind)
+	}
+
+	return fmt.Sprintf("The %s is no longer required.", kind)
+}
+
+func maxItems(n int) string {
+	if n == 0 {
+		return "unlimited"
+	}
+
+	return fmt.Sprint(n)
+}
+
+func equalDefaults(a, b cty.Value) bool {
+	if a == cty.NilVal || b == cty.NilVal {
+		return a == cty.NilVal && b == cty.NilVal
+	}
+
+	return a.RawEquals(b)
+}
+
+// unionNames returns the sorted names found in either of the sorted slices.
+func unionNames(a, b []string) []string {
+	seen := map[string]bool{}
+	res := []string{}
+
+	for _, names := range [][]string{a, b} {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				res = append(res, name)
+			}
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCompareSchemas(t *testing.T) {
+	changes := func(from, to hcldec.Spec) []string {
+		res := []string{}
+		for _, change := range parser.CompareSchemas(parser.Introspect(from), parser.Introspect(to)) {
+			prefix := "+ "
+			if change.Breaking {
+				prefix = "! "
+			}
+
+			res = append(res, prefix+change.Path+" "+change.Kind.String()+": "+change.Message)
+		}
+
+		return res
+	}
+
+	t.Run("CompareSchemas() finds no changes between equal schemas", func(t *testing.T) {
+		assert.Empty(t, changes(stationIntrospectSpec, stationIntrospectSpec))
+	})
+
+	t.Run("CompareSchemas() classifies attribute changes", func(t *testing.T) {
+		from := hcldec.ObjectSpec{
+			"name":    &hcldec.AttrSpec{Name: "name", Type: cty.String},
+			"channel": &hcldec.AttrSpec{Name: "channel", Type: cty.Number},
+			"radio":   &hcldec.AttrSpec{Name: "radio", Type: cty.String},
+			"zone":    &hcldec.AttrSpec{Name: "zone", Type: cty.String, Required: true},
+			"enabled": &hcldec.DefaultSpec{
+				Primary: &hcldec.AttrSpec{Name: "enabled", Type: cty.Bool},
+				Default: &hcldec.LiteralSpec{Value: cty.True},
+			},
+		}
+
+		to := hcldec.ObjectSpec{
+			"name":    &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+			"channel": &hcldec.AttrSpec{Name: "channel", Type: cty.String},
+			"zone":    &hcldec.AttrSpec{Name: "zone", Type: cty.Number},
+			"notes":   &hcldec.AttrSpec{Name: "notes", Type: cty.String},
+			"enabled": &hcldec.DefaultSpec{
+				Primary: &hcldec.AttrSpec{Name: "enabled", Type: cty.Bool},
+				Default: &hcldec.LiteralSpec{Value: cty.False},
+			},
+		}
+
+		assert.Equal(t, []string{
+			"+ channel type: The type changed from number to string.",
+			"+ enabled default: The default value changed.",
+			"! name required: The attribute is now required.",
+			"+ notes added: The attribute was added.",
+			"! radio removed: The attribute was removed.",
+			"+ zone required: The attribute is no longer required.",
+			"! zone type: The type changed from string to number.",
+		}, changes(from, to))
+	})
+
+	t.Run("CompareSchemas() classifies block changes", func(t *testing.T) {
+		from := hcldec.ObjectSpec{
+			"station": &hcldec.BlockMapSpec{
+				TypeName:   "station",
+				LabelNames: []string{"id"},
+				Nested: hcldec.ObjectSpec{
+					"unit":  &hcldec.BlockSpec{TypeName: "unit"},
+					"radio": &hcldec.BlockListSpec{TypeName: "radio", MaxItems: 2},
+				},
+			},
+			"zone": &hcldec.BlockMapSpec{TypeName: "zone", LabelNames: []string{"id"}},
+			"tags": &hcldec.BlockAttrsSpec{TypeName: "tags", ElementType: cty.String},
+		}
+
+		to := hcldec.ObjectSpec{
+			"station": &hcldec.BlockMapSpec{
+				TypeName:   "station",
+				LabelNames: []string{"name"},
+				Nested: hcldec.ObjectSpec{
+					"unit":  &hcldec.BlockListSpec{TypeName: "unit"},
+					"radio": &hcldec.BlockListSpec{TypeName: "radio", MinItems: 1, MaxItems: 1},
+					"crew":  &hcldec.BlockSpec{TypeName: "crew", Required: true},
+				},
+			},
+			"zone": &hcldec.BlockMapSpec{TypeName: "zone", LabelNames: []string{"region", "id"}},
+			"tags": &hcldec.BlockAttrsSpec{TypeName: "tags", ElementType: cty.Number},
+		}
+
+		assert.Equal(t, []string{
+			"+ station labels: The labels were renamed from id to name.",
+			"! station.*.crew added: The required block was added.",
+			"! station.*.radio required: The block is now required.",
+			"! station.*.radio limits: The minimum number of blocks changed from 0 to 1.",
+			"! station.*.radio limits: The maximum number of blocks changed from 2 to 1.",
+			"+ station.*.unit nesting: The nesting mode changed from single to list.",
+			"! tags type: The element type changed from string to number.",
+			"! zone labels: The number of labels changed from 1 to 2.",
+		}, changes(from, to))
+
+		reverse := changes(to, from)
+		assert.Contains(t, reverse, "! station.*.crew removed: The block was removed.")
+		assert.Contains(t, reverse, "! station.*.unit nesting: The nesting mode changed from list to single.")
+		assert.Contains(t, reverse, "+ station.*.radio limits: The maximum number of blocks changed from 1 to 2.")
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser
+
+// DescribedBlockDefinition is implemented by BlockDefinition's that provide Metadata. The
+// metadata is used for reference documentation, editor hover information, JSON Schema export
+// and deprecation warnings.
+type DescribedBlockDefinition interface {
+	BlockDefinition
+
+	// Metadata must return the Metadata describing the block and its contents.
+	Metadata() *Metadata
+}
+
+// Metadata describes a BlockDefinition. Since holds the version the block was added in and
+// Deprecation, when not empty, explains why the block is deprecated and what to use instead.
+// Examples are written in HCL.
+//
+// Attributes describes the blocks and attributes within the definition keyed by their path
+// from the root of the configuration, in the form used by SchemaBody.Lookup where each label is
+// given as SchemaWildcard, such as "station.*.unit.callsign".
+type Metadata struct {
+	Description string
+	Examples    []string
+	Since       string
+	Deprecation string
+	Attributes  map[string]*AttributeMetadata
+}
+
+// AttributeMetadata describes a block or attribute within a BlockDefinition.
+type AttributeMetadata struct {
+	Description string
+	Since       string
+	Deprecation string
+}
+
+// Attribute returns the AttributeMetadata of the block or attribute at the path, such as
+// "station.*.unit.callsign", or nil when it is not described.
+func (m *Metadata) Attribute(path string) *AttributeMetadata {
+	if m == nil {
+		return nil
+	}
+
+	return m.Attributes[path]
+}
+
+// Metadata returns the Metadata of the registration's definition. Empty Metadata is returned
+// when the definition does not implement DescribedBlockDefinition.
+func (r *Registration) Metadata() *Metadata {
+	if def, ok := r.Definition.(DescribedBlockDefinition); ok {
+		if meta := def.Metadata(); meta != nil {
+			return meta
+		}
+	}
+
+	return &Metadata{}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ parser.DescribedBlockDefinition = (*describedDefSpec)(nil)
+
+type describedDefSpec struct{}
+
+func (s *describedDefSpec) Spec() hcldec.Spec {
+	return hcldec.ObjectSpec{}
+}
+
+func (s *describedDefSpec) Metadata() *parser.Metadata {
+	return &parser.Metadata{
+		Description: "Described block.",
+		Attributes: map[string]*parser.AttributeMetadata{
+			"described.*.name": {Description: "The name."},
+		},
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("Registration returns the metadata of described definitions", func(t *testing.T) {
+		reg := &parser.Registration{BlockName: "described", Definition: &describedDefSpec{}}
+
+		assert.Equal(t, "Described block.", reg.Metadata().Description)
+		assert.Equal(t, "The name.", reg.Metadata().Attribute("described.*.name").Description)
+		assert.Nil(t, reg.Metadata().Attribute("described.*.missing"))
+	})
+
+	t.Run("Registration returns empty metadata for other definitions", func(t *testing.T) {
+		reg := &parser.Registration{BlockName: "test", Definition: &testSpecBlockDef{}}
+
+		assert.Equal(t, &parser.Metadata{}, reg.Metadata())
+		assert.Nil(t, reg.Metadata().Attribute("test"))
+	})
+}
@@ -22,6 +22,68 @@ type InjectableVariables map[string]cty.Value
 // Spec()
 type InjectableFunctions map[string]function.Function
 
+// Mark is used to annotate cty.Value's injected into the hcl.EvalContext with additional
+// characteristics that propagate through expressions.
+type Mark string
+
+// Sensitive marks an injected value that must never be shown to end-users, such as when
+// rendering the variables referenced by a diagnostic.
+const Sensitive Mark = "sensitive"
+
+// Unmark returns the value with the marks removed from it and from every value nested within it,
+// along with all of the marks found. It is used in place of cty.Value.UnmarkDeep which panics on
+// marked collections.
+func Unmark(val cty.Value) (cty.Value, cty.ValueMarks) {
+	val, marks := val.Unmark()
+	if marks == nil {
+		marks = cty.ValueMarks{}
+	}
+
+	if !val.IsKnown() || val.IsNull() {
+		return val, marks
+	}
+
+	ty := val.Type()
+	if !ty.IsCollectionType() && !ty.IsTupleType() && !ty.IsObjectType() {
+		return val, marks
+	}
+
+	if val.LengthInt() == 0 {
+		return val, marks
+	}
+
+	elems := []cty.Value{}
+	attrs := map[string]cty.Value{}
+
+	for it := val.ElementIterator(); it.Next(); {
+		key, elem := it.Element()
+
+		elem, elemMarks := Unmark(elem)
+		for mark := range elemMarks {
+			marks[mark] = struct{}{}
+		}
+
+		if ty.IsMapType() || ty.IsObjectType() {
+			attrs[key.AsString()] = elem
+		} else {
+			elems = append(elems, elem)
+		}
+	}
+
+	switch {
+	case ty.IsListType():
+		return cty.ListVal(elems), marks
+	case ty.IsSetType():
+		return cty.SetVal(elems), marks
+	case ty.IsTupleType():
+		return cty.TupleVal(elems), marks
+	case ty.IsMapType():
+		return cty.MapVal(attrs), marks
+	default:
+		return cty.ObjectVal(attrs), marks
+	}
+}
+
 // NamedBlockDefinitions represents a slice of individual NamedBlockDefinition's pre-ordered
 // in the order they should be processed.
 type NamedBlockDefinitions []NamedBlockDefinition
@@ -66,3 +128,17 @@ type FunctionInjector interface {
 	// created from this block to be available for all that follow.
 	Functions(v cty.Value) InjectableFunctions
 }
+
+// InjectionDescriber is implemented by VariableInjector's and FunctionInjector's that list what
+// they inject without being given a value. This allows documentation to describe the injected
+// variables and functions without parsing any configuration. Injectors that do not implement
+// it are documented without their injections.
+type InjectionDescriber interface {
+	BlockDefinition
+
+	// InjectedVariables must return the names of the variables returned by Variables.
+	InjectedVariables() []string
+
+	// InjectedFunctions must return the functions returned by Functions.
+	InjectedFunctions() InjectableFunctions
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser
+
+// Referencer is implemented by BlockDefinition's with attributes that refer to other blocks by
+// their label, such as the station of a unit written as `station = "st12"`.
+type Referencer interface {
+	BlockDefinition
+
+	// References must return the Reference's made by the attributes of the BlockDefinition.
+	References() []*Reference
+}
+
+// Reference declares that an attribute contains the first label of a block of another, or the
+// same, registration. The attribute may contain a single label or a list, set or tuple of them.
+//
+// Attribute is the path of the attribute and Target the path of the referenced blocks, both in
+// the form used by SchemaBody.Lookup where each label is given as SchemaWildcard, such as
+// "unit.*.station" and "station".
+type Reference struct {
+	Attribute string
+	Target    string
+}
@@ -53,10 +53,23 @@ type Registration struct {
 //
 // The registrar also allows injecting functions after specific hcldec.Spec's are processed,
 // though in general this should be avoided.
+//
+// When Strict is true any block or attribute not described by a registration is reported as an
+// error rather than being ignored.
 type Registrar struct {
 	NextOrder           int
 	IncreaseNextOrderBy int
+	Strict              bool
 	registrations       []*Registration
+	injections          map[string]*Injection
+}
+
+// Injection records the Registration whose VariableInjector produced a variable during the
+// last call to Parse along with the value it was given.
+type Injection struct {
+	Variable     string
+	Value        cty.Value
+	Registration *Registration
 }
 
 // NewRegistrar creates a new Registrar instance, returning the pointer to be used for registering
@@ -66,6 +79,7 @@ func NewRegistrar(increaseNextOrderBy int) *Registrar {
 		NextOrder:           0,
 		IncreaseNextOrderBy: increaseNextOrderBy,
 		registrations:       make([]*Registration, 0),
+		injections:          map[string]*Injection{},
 	}
 }
 
@@ -132,12 +146,20 @@ func (r *Registrar) Parse(body hcl.Body, ctx *hcl.EvalContext) hcl.Diagnostics {
 		},
 	)
 
+	r.injections = map[string]*Injection{}
+
 	var lastBody = body
 	var lastDiags = hcl.Diagnostics{}
 
 	for _, reg := range ordered {
 		val, body, diags := hcldec.PartialDecode(lastBody, reg.Definition.Spec(), ctx)
 		lastDiags = lastDiags.Extend(diags)
+
+		// if a Validator
+		if v, ok := reg.Definition.(Validator); ok {
+			lastDiags = lastDiags.Extend(validate(lastBody, reg.Definition.Spec(), v.Rules(), ctx))
+		}
+
 		lastBody = body
 
 		// if a FunctionInjector
@@ -151,9 +173,33 @@ func (r *Registrar) Parse(body hcl.Body, ctx *hcl.EvalContext) hcl.Diagnostics {
 		if inj, ok := reg.Definition.(VariableInjector); ok {
 			for k, v := range inj.Variables(val) {
 				ctx.Variables[k] = v
+				r.injections[k] = &Injection{Variable: k, Value: v, Registration: reg}
 			}
 		}
 	}
 
+	// an empty schema reports everything left in the body as unsupported
+	if r.Strict {
+		_, diags := lastBody.Content(&hcl.BodySchema{})
+		lastDiags = lastDiags.Extend(diags)
+	}
+
 	return lastDiags
 }
+
+// Injections returns the Injection for each variable injected during the last call to Parse
+// ordered by the name of the variable. When more than one VariableInjector injects the same
+// variable the last one, whose value is the one in the hcl.EvalContext, is returned.
+func (r *Registrar) Injections() []*Injection {
+	res := make([]*Injection, 0, len(r.injections))
+
+	for _, inj := range r.injections {
+		res = append(res, inj)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Variable < res[j].Variable
+	})
+
+	return res
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/responserms/spec/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/zclconf/go-cty/cty"
@@ -183,4 +184,30 @@ func TestParse(t *testing.T) {
 		assert.IsType(t, function.Function{}, ctx.Functions["noop"])
 		assert.IsType(t, cty.Value{}, ctx.Variables["one"])
 	})
+	t.Run("ensure injected variables are recorded with their registration", func(t *testing.T) {
+		reg := parser.NewRegistrar(1)
+		reg.RegisterBlock("vars", &varDefSpec{})
+
+		reg.Parse(hcl.EmptyBody(), &hcl.EvalContext{})
+
+		assert.Len(t, reg.Injections(), 1)
+		assert.Equal(t, "one", reg.Injections()[0].Variable)
+		assert.Equal(t, "vars", reg.Injections()[0].Registration.BlockName)
+		assert.Equal(t, cty.NumberIntVal(1), reg.Injections()[0].Value)
+	})
+	t.Run("ensure Strict reports content not described by a registration", func(t *testing.T) {
+		file, _ := hclsyntax.ParseConfig([]byte("test = \"value\"\nunknown = true\n"), "test.hcl", hcl.InitialPos)
+
+		reg := parser.NewRegistrar(1)
+		reg.RegisterBlock("test", &testSpecBlockDef{})
+
+		assert.False(t, reg.Parse(file.Body, &hcl.EvalContext{}).HasErrors())
+
+		reg.Strict = true
+		diags := reg.Parse(file.Body, &hcl.EvalContext{})
+
+		assert.Len(t, diags, 1)
+		assert.Equal(t, "Unsupported argument", diags[0].Summary)
+		assert.Equal(t, 2, diags[0].Subject.Start.Line)
+	})
 }
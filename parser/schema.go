@@ -0,0 +1,318 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SchemaWildcard is the path segment used in place of each label of a block.
+const SchemaWildcard = "*"
+
+// NestingMode describes how a block may be repeated within its body.
+type NestingMode int
+
+// supported nesting modes, named after the hcldec.Spec describing the block
+const (
+	// NestingSingle is a single block as described by a hcldec.BlockSpec.
+	NestingSingle NestingMode = iota
+
+	// NestingList is any number of blocks as described by a hcldec.BlockListSpec.
+	NestingList
+
+	// NestingSet is any number of unique blocks as described by a hcldec.BlockSetSpec.
+	NestingSet
+
+	// NestingTuple is any number of blocks as described by a hcldec.BlockTupleSpec.
+	NestingTuple
+
+	// NestingMap is any number of labeled blocks as described by a hcldec.BlockMapSpec.
+	NestingMap
+
+	// NestingObject is any number of labeled blocks as described by a hcldec.BlockObjectSpec.
+	NestingObject
+
+	// NestingAttrs is a single block of arbitrary attributes as described by a
+	// hcldec.BlockAttrsSpec.
+	NestingAttrs
+)
+
+// String returns the name of the NestingMode.
+func (m NestingMode) String() string {
+	switch m {
+	case NestingSingle:
+		return "single"
+	case NestingList:
+		return "list"
+	case NestingSet:
+		return "set"
+	case NestingTuple:
+		return "tuple"
+	case NestingMap:
+		return "map"
+	case NestingObject:
+		return "object"
+	case NestingAttrs:
+		return "attrs"
+	default:
+		return "unknown"
+	}
+}
+
+// SchemaBody describes the attributes and blocks allowed within a body. Path is the path of the
+// body from the root of the configuration, such as "station.*" for the body of a block labeled
+// with a single label, and is empty for the root body.
+type SchemaBody struct {
+	Path       string
+	Spec       hcldec.Spec
+	Attributes map[string]*SchemaAttribute
+	Blocks     map[string]*SchemaBlock
+}
+
+// SchemaAttribute describes an attribute. Default is cty.NilVal when the attribute does not
+// have a literal default value.
+type SchemaAttribute struct {
+	Name     string
+	Path     string
+	Type     cty.Type
+	Required bool
+	Default  cty.Value
+	Spec     *hcldec.AttrSpec
+}
+
+// SchemaBlock describes a block. Body is nil for blocks of NestingAttrs, which instead accept
+// any attribute of the ElementType. MinItems and MaxItems are zero when the number of blocks is
+// not limited.
+type SchemaBlock struct {
+	TypeName    string
+	Path        string
+	LabelNames  []string
+	Nesting     NestingMode
+	Required    bool
+	MinItems    int
+	MaxItems    int
+	ElementType cty.Type
+	Body        *SchemaBody
+	Spec        hcldec.Spec
+}
+
+// Introspect returns the SchemaBody described by the hcldec.Spec, such as the result of a
+// BlockDefinition's Spec() method. Specs that only transform or validate values are looked
+// through so the model contains the blocks and attributes they wrap.
+func Introspect(spec hcldec.Spec) *SchemaBody {
+	return introspectBody(spec, "")
+}
+
+// Schema returns the SchemaBody described by the registration's definition.
+func (r *Registration) Schema() *SchemaBody {
+	return Introspect(r.Definition.Spec())
+}
+
+func introspectBody(spec hcldec.Spec, path string) *SchemaBody {
+	body := &SchemaBody{
+		Path:       path,
+		Spec:       spec,
+		Attributes: map[string]*SchemaAttribute{},
+		Blocks:     map[string]*SchemaBlock{},
+	}
+
+	var walk func(spec hcldec.Spec, def cty.Value)
+	walk = func(spec hcldec.Spec, def cty.Value) {
+		switch s := spec.(type) {
+		case hcldec.ObjectSpec:
+			for _, child := range s {
+				walk(child, cty.NilVal)
+			}
+		case hcldec.TupleSpec:
+			for _, child := range s {
+				walk(child, cty.NilVal)
+			}
+		case *hcldec.AttrSpec:
+			body.Attributes[s.Name] = &SchemaAttribute{
+				Name:     s.Name,
+				Path:     joinSchemaPath(path, s.Name),
+				Type:     s.Type,
+				Required: s.Required,
+				Default:  def,
+				Spec:     s,
+			}
+		case *hcldec.DefaultSpec:
+			if lit, ok := s.Default.(*hcldec.LiteralSpec); ok {
+				def = lit.Value
+			}
+
+			walk(s.Primary, def)
+			walk(s.Default, cty.NilVal)
+		case *hcldec.TransformExprSpec:
+			walk(s.Wrapped, def)
+		case *hcldec.TransformFuncSpec:
+			walk(s.Wrapped, def)
+		case *hcldec.ValidateSpec:
+			walk(s.Wrapped, def)
+		case *hcldec.BlockSpec:
+			body.addBlock(&SchemaBlock{TypeName: s.TypeName, Nesting: NestingSingle, Required: s.Required, Spec: s}, s.Nested)
+		case *hcldec.BlockListSpec:
+			body.addBlock(&SchemaBlock{
+				TypeName: s.TypeName, Nesting: NestingList, Required: s.MinItems > 0,
+				MinItems: s.MinItems, MaxItems: s.MaxItems, Spec: s,
+			}, s.Nested)
+		case *hcldec.BlockSetSpec:
+			body.addBlock(&SchemaBlock{
+				TypeName: s.TypeName, Nesting: NestingSet, Required: s.MinItems > 0,
+				MinItems: s.MinItems, MaxItems: s.MaxItems, Spec: s,
+			}, s.Nested)
+		case *hcldec.BlockTupleSpec:
+			body.addBlock(&SchemaBlock{
+				TypeName: s.TypeName, Nesting: NestingTuple, Required: s.MinItems > 0,
+				MinItems: s.MinItems, MaxItems: s.MaxItems, Spec: s,
+			}, s.Nested)
+		case *hcldec.BlockMapSpec:
+			body.addBlock(&SchemaBlock{TypeName: s.TypeName, Nesting: NestingMap, LabelNames: s.LabelNames, Spec: s}, s.Nested)
+		case *hcldec.BlockObjectSpec:
+			body.addBlock(&SchemaBlock{TypeName: s.TypeName, Nesting: NestingObject, LabelNames: s.LabelNames, Spec: s}, s.Nested)
+		case *hcldec.BlockAttrsSpec:
+			body.addBlock(&SchemaBlock{
+				TypeName: s.TypeName, Nesting: NestingAttrs, Required: s.Required,
+				ElementType: s.ElementType, Spec: s,
+			}, nil)
+		}
+	}
+
+	if spec != nil {
+		walk(spec, cty.NilVal)
+	}
+
+	return body
+}
+
+// addBlock adds the block to the body, completing its path and introspecting the nested spec
+// unless the block is of NestingAttrs.
+func (b *SchemaBody) addBlock(block *SchemaBlock, nested hcldec.Spec) {
+	if block.LabelNames == nil {
+		block.LabelNames = []string{}
+	}
+
+	block.Path = joinSchemaPath(b.Path, block.TypeName)
+
+	if block.Nesting == NestingAttrs {
+		b.Blocks[block.TypeName] = block
+		return
+	}
+
+	bodyPath := block.Path
+	for range block.LabelNames {
+		bodyPath = joinSchemaPath(bodyPath, SchemaWildcard)
+	}
+
+	block.ElementType = cty.NilType
+	block.Body = introspectBody(nested, bodyPath)
+
+	b.Blocks[block.TypeName] = block
+}
+
+// AttributeNames returns the names of the attributes in lexical order.
+func (b *SchemaBody) AttributeNames() []string {
+	names := make([]string, 0, len(b.Attributes))
+	for name := range b.Attributes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// BlockTypes returns the types of the blocks in lexical order.
+func (b *SchemaBody) BlockTypes() []string {
+	names := make([]string, 0, len(b.Blocks))
+	for name := range b.Blocks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Walk calls fn for each attribute and block within the body and all nested bodies, depth
+// first. Within each body the attributes are visited before the blocks, each in lexical order,
+// and exactly one of attr and block is non-nil. Returning false from fn skips the contents of
+// the block.
+func (b *SchemaBody) Walk(fn func(attr *SchemaAttribute, block *SchemaBlock) bool) {
+	for _, name := range b.AttributeNames() {
+		fn(b.Attributes[name], nil)
+	}
+
+	for _, name := range b.BlockTypes() {
+		block := b.Blocks[name]
+		if fn(nil, block) && block.Body != nil {
+			block.Body.Walk(fn)
+		}
+	}
+}
+
+// Lookup returns the attribute or block at the path, such as "station.*.unit.callsign", where
+// each label of a block is given as SchemaWildcard. Nil is returned for both when the path does
+// not exist. Attributes within blocks of NestingAttrs are not described and cannot be found.
+func (b *SchemaBody) Lookup(path string) (*SchemaAttribute, *SchemaBlock) {
+	if path == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(path, ".")
+	body := b
+
+	for len(segments) > 0 {
+		if body == nil {
+			return nil, nil
+		}
+
+		name := segments[0]
+		segments = segments[1:]
+
+		if attr, ok := body.Attributes[name]; ok && len(segments) == 0 {
+			return attr, nil
+		}
+
+		block, ok := body.Blocks[name]
+		if !ok {
+			return nil, nil
+		}
+
+		if len(segments) == 0 {
+			return nil, block
+		}
+
+		for range block.LabelNames {
+			if len(segments) == 0 || segments[0] != SchemaWildcard {
+				return nil, nil
+			}
+
+			segments = segments[1:]
+		}
+
+		if len(segments) == 0 {
+			return nil, nil
+		}
+
+		body = block.Body
+	}
+
+	return nil, nil
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
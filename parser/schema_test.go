@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var stationIntrospectSpec = &hcldec.BlockMapSpec{
+	TypeName:   "station",
+	LabelNames: []string{"id"},
+	Nested: hcldec.ObjectSpec{
+		"name": &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+		"enabled": &hcldec.DefaultSpec{
+			Primary: &hcldec.AttrSpec{Name: "enabled", Type: cty.Bool},
+			Default: &hcldec.LiteralSpec{Value: cty.True},
+		},
+		"unit": &hcldec.BlockListSpec{
+			TypeName: "unit",
+			MinItems: 1,
+			MaxItems: 4,
+			Nested: hcldec.ObjectSpec{
+				"callsign": &hcldec.AttrSpec{Name: "callsign", Type: cty.String, Required: true},
+			},
+		},
+		"tags": &hcldec.BlockAttrsSpec{TypeName: "tags", ElementType: cty.String},
+	},
+}
+
+func TestIntrospect(t *testing.T) {
+	t.Run("Introspect() describes blocks, labels and nesting modes", func(t *testing.T) {
+		schema := parser.Introspect(hcldec.ObjectSpec{"station": stationIntrospectSpec})
+
+		assert.Equal(t, []string{"station"}, schema.BlockTypes())
+
+		station := schema.Blocks["station"]
+		assert.Equal(t, "station", station.Path)
+		assert.Equal(t, []string{"id"}, station.LabelNames)
+		assert.Equal(t, parser.NestingMap, station.Nesting)
+		assert.False(t, station.Required)
+		assert.Equal(t, "station.*", station.Body.Path)
+		assert.Equal(t, []string{"enabled", "name"}, station.Body.AttributeNames())
+		assert.Equal(t, []string{"tags", "unit"}, station.Body.BlockTypes())
+
+		unit := station.Body.Blocks["unit"]
+		assert.Equal(t, "station.*.unit", unit.Path)
+		assert.Equal(t, parser.NestingList, unit.Nesting)
+		assert.Equal(t, "list", unit.Nesting.String())
+		assert.True(t, unit.Required)
+		assert.Equal(t, 1, unit.MinItems)
+		assert.Equal(t, 4, unit.MaxItems)
+		assert.Equal(t, []string{}, unit.LabelNames)
+
+		tags := station.Body.Blocks["tags"]
+		assert.Equal(t, parser.NestingAttrs, tags.Nesting)
+		assert.Equal(t, cty.String, tags.ElementType)
+		assert.Nil(t, tags.Body)
+	})
+
+	t.Run("Introspect() describes attribute types, required flags and defaults", func(t *testing.T) {
+		body := parser.Introspect(stationIntrospectSpec).Blocks["station"].Body
+
+		name := body.Attributes["name"]
+		assert.Equal(t, "station.*.name", name.Path)
+		assert.Equal(t, cty.String, name.Type)
+		assert.True(t, name.Required)
+		assert.Equal(t, cty.NilVal, name.Default)
+
+		enabled := body.Attributes["enabled"]
+		assert.Equal(t, cty.Bool, enabled.Type)
+		assert.False(t, enabled.Required)
+		assert.True(t, enabled.Default.RawEquals(cty.True))
+	})
+
+	t.Run("Introspect() handles a nil spec", func(t *testing.T) {
+		schema := parser.Introspect(nil)
+
+		assert.Empty(t, schema.Attributes)
+		assert.Empty(t, schema.Blocks)
+	})
+
+	t.Run("Lookup() finds attributes and blocks by path", func(t *testing.T) {
+		schema := parser.Introspect(stationIntrospectSpec)
+
+		attr, block := schema.Lookup("station.*.unit.callsign")
+		assert.Nil(t, block)
+		if assert.NotNil(t, attr) {
+			assert.Equal(t, "callsign", attr.Name)
+		}
+
+		attr, block = schema.Lookup("station.*.unit")
+		assert.Nil(t, attr)
+		if assert.NotNil(t, block) {
+			assert.Equal(t, "unit", block.TypeName)
+		}
+
+		for _, path := range []string{"", "station.unit", "station.*", "station.*.missing", "station.*.tags.name"} {
+			attr, block = schema.Lookup(path)
+			assert.Nil(t, attr, path)
+			assert.Nil(t, block, path)
+		}
+	})
+
+	t.Run("Walk() visits attributes before blocks, depth first", func(t *testing.T) {
+		paths := []string{}
+
+		parser.Introspect(stationIntrospectSpec).Walk(func(attr *parser.SchemaAttribute, block *parser.SchemaBlock) bool {
+			if attr != nil {
+				paths = append(paths, attr.Path)
+				return true
+			}
+
+			paths = append(paths, block.Path)
+
+			return block.TypeName != "unit"
+		})
+
+		assert.Equal(t, []string{
+			"station",
+			"station.*.enabled",
+			"station.*.name",
+			"station.*.tags",
+			"station.*.unit",
+		}, paths)
+	})
+
+	t.Run("Registration returns the schema of its definition", func(t *testing.T) {
+		reg := &parser.Registration{BlockName: "test", Definition: &testSpecBlockDef{}}
+
+		assert.Equal(t, parser.Introspect(reg.Definition.Spec()), reg.Schema())
+	})
+}
@@ -0,0 +1,395 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// diagnostic messages
+const (
+	DiagInvalidValue       = "Invalid attribute value"
+	DiagInvalidCombination = "Invalid attribute combination"
+)
+
+// Validator is implemented by BlockDefinition's that validate their configuration beyond the
+// types and required attributes checked by their hcldec.Spec. The rules are checked after the
+// registration's Spec has been decoded, using the same hcl.EvalContext.
+type Validator interface {
+	BlockDefinition
+
+	// Rules must return the Rule's to check, in order.
+	Rules() []*Rule
+}
+
+// Rule validates the attributes of every block at Path, which is the path of the blocks' body in
+// the form used by SchemaBody.Lookup such as "station.*.unit", where each label is given as
+// SchemaWildcard. An empty Path validates the attributes of the root body.
+//
+// Attribute is the name of the attribute checked by rules validating a single attribute and
+// Values, when not empty, lists every value the attribute accepts so editors can offer them as
+// completions. Rules created by the helpers, such as Pattern and OneOf, set these fields.
+type Rule struct {
+	Path      string
+	Attribute string
+	Values    []cty.Value
+	Check     func(block *RuleBlock) hcl.Diagnostics
+}
+
+// RuleBlock is a single block, or the root body, being validated by a Rule.
+type RuleBlock struct {
+	// Range is the range of the block's type and labels, or the start of the root body.
+	Range hcl.Range
+
+	// Attributes are the attributes set within the block keyed by name. Attributes whose value
+	// cannot be evaluated or converted to the attribute's type have an unknown value as the
+	// hcldec.Spec already reports them.
+	Attributes map[string]*RuleAttribute
+}
+
+// RuleAttribute is an attribute set within a RuleBlock with its value converted to the type of
+// the attribute. The value is unmarked, so a Rule must not include it in diagnostics as it may
+// have been computed from a Sensitive value.
+type RuleAttribute struct {
+	Attribute *hcl.Attribute
+	Value     cty.Value
+}
+
+// Diagnostic returns an error diagnostic with the given summary and detail for the value of the
+// attribute.
+func (a *RuleAttribute) Diagnostic(summary, detail string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  a.Attribute.Expr.Range().Ptr(),
+		Context:  a.Attribute.Range.Ptr(),
+	}
+}
+
+// Pattern returns a Rule requiring the string attribute at the path, such as
+// "station.*.unit.callsign", to match the regular expression.
+func Pattern(path string, re *regexp.Regexp) *Rule {
+	return attributeRule(path, func(name string, val cty.Value) string {
+		if val.Type() != cty.String || re.MatchString(val.AsString()) {
+			return ""
+		}
+
+		return fmt.Sprintf("The value of %q must match the pattern %s.", name, re)
+	})
+}
+
+// Min returns a Rule requiring the number attribute at the path to be at least min.
+func Min(path string, min float64) *Rule {
+	return attributeRule(path, func(name string, val cty.Value) string {
+		if val.Type() != cty.Number || val.AsBigFloat().Cmp(big.NewFloat(min)) >= 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("The value of %q must be at least %v.", name, min)
+	})
+}
+
+// Max returns a Rule requiring the number attribute at the path to be at most max.
+func Max(path string, max float64) *Rule {
+	return attributeRule(path, func(name string, val cty.Value) string {
+		if val.Type() != cty.Number || val.AsBigFloat().Cmp(big.NewFloat(max)) <= 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("The value of %q must be at most %v.", name, max)
+	})
+}
+
+// Length returns a Rule requiring the length of the string or collection attribute at the path
+// to be between min and max, inclusive. A max of zero does not limit the length.
+func Length(path string, min, max int) *Rule {
+	return attributeRule(path, func(name string, val cty.Value) string {
+		var length int
+
+		switch ty := val.Type(); {
+		case ty == cty.String:
+			length = len([]rune(val.AsString()))
+		case ty.IsCollectionType() || ty.IsTupleType():
+			length = val.LengthInt()
+		default:
+			return ""
+		}
+
+		if length < min {
+			return fmt.Sprintf("The length of %q must be at least %d.", name, min)
+		}
+
+		if max > 0 && length > max {
+			return fmt.Sprintf("The length of %q must be at most %d.", name, max)
+		}
+
+		return ""
+	})
+}
+
+// Enum returns a Rule requiring the attribute at the path to be one of the values.
+func Enum(path string, values ...cty.Value) *Rule {
+	rule := attributeRule(path, func(name string, val cty.Value) string {
+		for _, v := range values {
+			if eq := val.Equals(v); eq.IsKnown() && eq.True() {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("The value of %q must be one of %s.", name, ruleValues(values))
+	})
+
+	rule.Values = values
+
+	return rule
+}
+
+// OneOf returns a Rule requiring exactly one of the named attributes to be set within every
+// block at the path.
+func OneOf(path string, names ...string) *Rule {
+	return &Rule{
+		Path: path,
+		Check: func(block *RuleBlock) hcl.Diagnostics {
+			set := setAttributes(block, names)
+			if len(set) == 0 {
+				return hcl.Diagnostics{
+					{
+						Severity: hcl.DiagError,
+						Summary:  DiagInvalidCombination,
+						Detail:   fmt.Sprintf("Exactly one of %s must be set.", ruleNames(names)),
+						Subject:  block.Range.Ptr(),
+					},
+				}
+			}
+
+			return exclusive(set, names)
+		},
+	}
+}
+
+// MutuallyExclusive returns a Rule allowing at most one of the named attributes to be set
+// within every block at the path.
+func MutuallyExclusive(path string, names ...string) *Rule {
+	return &Rule{
+		Path: path,
+		Check: func(block *RuleBlock) hcl.Diagnostics {
+			return exclusive(setAttributes(block, names), names)
+		},
+	}
+}
+
+// RequiredWith returns a Rule requiring each of the attributes named by with to be set within
+// every block at the path that sets the named attribute.
+func RequiredWith(path string, name string, with ...string) *Rule {
+	return &Rule{
+		Path: path,
+		Check: func(block *RuleBlock) hcl.Diagnostics {
+			attr, ok := block.Attributes[name]
+			if !ok {
+				return nil
+			}
+
+			diags := hcl.Diagnostics{}
+			for _, required := range with {
+				if _, ok := block.Attributes[required]; !ok {
+					diags = diags.Append(attr.Diagnostic(DiagInvalidCombination,
+						fmt.Sprintf("The attribute %q requires %q to be set.", name, required)))
+				}
+			}
+
+			return diags
+		},
+	}
+}
+
+// attributeRule returns a Rule for the attribute at the path calling check with the value of
+// the attribute, when it is known, which returns the detail of the diagnostic or an empty string
+// when the value is valid.
+func attributeRule(path string, check func(name string, val cty.Value) string) *Rule {
+	blockPath, name := "", path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		blockPath, name = path[:i], path[i+1:]
+	}
+
+	return &Rule{
+		Path:      blockPath,
+		Attribute: name,
+		Check: func(block *RuleBlock) hcl.Diagnostics {
+			attr, ok := block.Attributes[name]
+			if !ok || attr.Value.IsNull() || !attr.Value.IsWhollyKnown() {
+				return nil
+			}
+
+			if detail := check(name, attr.Value); detail != "" {
+				return hcl.Diagnostics{attr.Diagnostic(DiagInvalidValue, detail)}
+			}
+
+			return nil
+		},
+	}
+}
+
+// setAttributes returns the named attributes set within the block, in the order of names.
+func setAttributes(block *RuleBlock, names []string) []*RuleAttribute {
+	res := []*RuleAttribute{}
+
+	for _, name := range names {
+		if attr, ok := block.Attributes[name]; ok {
+			res = append(res, attr)
+		}
+	}
+
+	return res
+}
+
+// exclusive returns a diagnostic for each set attribute after the first.
+func exclusive(set []*RuleAttribute, names []string) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	for _, attr := range set[min(len(set), 1):] {
+		diags = diags.Append(attr.Diagnostic(DiagInvalidCombination,
+			fmt.Sprintf("Only one of %s may be set but %q is set along with %q.", ruleNames(names), attr.Attribute.Name, set[0].Attribute.Name)))
+	}
+
+	return diags
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func ruleNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func ruleValues(values []cty.Value) string {
+	res := make([]string, len(values))
+	for i, val := range values {
+		res[i] = string(hclwrite.TokensForValue(val).Bytes())
+	}
+
+	return strings.Join(res, ", ")
+}
+
+// validate checks the rules against the body using the registration's spec.
+func validate(body hcl.Body, spec hcldec.Spec, rules []*Rule, ctx *hcl.EvalContext) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	schema := Introspect(spec)
+
+	for _, rule := range rules {
+		if rule.Check == nil {
+			continue
+		}
+
+		for _, block := range ruleBlocks(body, schema, rule.Path, ctx) {
+			diags = diags.Extend(rule.Check(block))
+		}
+	}
+
+	return diags
+}
+
+// ruleTarget is a body found while resolving the path of a Rule.
+type ruleTarget struct {
+	body   hcl.Body
+	schema *SchemaBody
+	rng    hcl.Range
+}
+
+// ruleBlocks returns a RuleBlock for every block at the path.
+func ruleBlocks(body hcl.Body, schema *SchemaBody, path string, ctx *hcl.EvalContext) []*RuleBlock {
+	targets := []*ruleTarget{{body: body, schema: schema, rng: body.MissingItemRange()}}
+
+	segments := []string{}
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+
+	for len(segments) > 0 {
+		typeName := segments[0]
+		segments = segments[1:]
+
+		block, ok := schema.Blocks[typeName]
+		if !ok || block.Body == nil {
+			return nil
+		}
+
+		for range block.LabelNames {
+			if len(segments) == 0 || segments[0] != SchemaWildcard {
+				return nil
+			}
+
+			segments = segments[1:]
+		}
+
+		next := []*ruleTarget{}
+
+		for _, target := range targets {
+			content, _, _ := target.body.PartialContent(hcldec.ImpliedSchema(schema.Spec))
+			for _, b := range content.Blocks {
+				if b.Type == typeName {
+					next = append(next, &ruleTarget{body: b.Body, schema: block.Body, rng: b.DefRange})
+				}
+			}
+		}
+
+		schema = block.Body
+		targets = next
+	}
+
+	res := []*RuleBlock{}
+
+	for _, target := range targets {
+		block := &RuleBlock{Range: target.rng, Attributes: map[string]*RuleAttribute{}}
+
+		content, _, _ := target.body.PartialContent(hcldec.ImpliedSchema(target.schema.Spec))
+		for name, attr := range content.Attributes {
+			schemaAttr, ok := target.schema.Attributes[name]
+			if !ok {
+				continue
+			}
+
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				val = cty.UnknownVal(schemaAttr.Type)
+			}
+
+			// marks such as Sensitive are removed so the rules may inspect the value
+			val, _ = Unmark(val)
+
+			val, err := convert.Convert(val, schemaAttr.Type)
+			if err != nil {
+				val = cty.UnknownVal(schemaAttr.Type)
+			}
+
+			block.Attributes[name] = &RuleAttribute{Attribute: attr, Value: val}
+		}
+
+		res = append(res, block)
+	}
+
+	return res
+}
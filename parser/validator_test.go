@@ -0,0 +1,197 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package parser_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type testRadioDef struct{}
+
+func (t *testRadioDef) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "radio",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"callsign":  &hcldec.AttrSpec{Name: "callsign", Type: cty.String},
+			"band":      &hcldec.AttrSpec{Name: "band", Type: cty.String},
+			"channel":   &hcldec.AttrSpec{Name: "channel", Type: cty.Number},
+			"frequency": &hcldec.AttrSpec{Name: "frequency", Type: cty.Number},
+			"talkgroup": &hcldec.AttrSpec{Name: "talkgroup", Type: cty.Number},
+			"encrypted": &hcldec.AttrSpec{Name: "encrypted", Type: cty.Bool},
+			"key":       &hcldec.AttrSpec{Name: "key", Type: cty.String},
+			"aliases":   &hcldec.AttrSpec{Name: "aliases", Type: cty.List(cty.String)},
+		},
+	}
+}
+
+func (t *testRadioDef) Rules() []*parser.Rule {
+	return []*parser.Rule{
+		parser.Pattern("radio.*.callsign", regexp.MustCompile(`^[A-Z]+[0-9]+$`)),
+		parser.Enum("radio.*.band", cty.StringVal("vhf"), cty.StringVal("uhf")),
+		parser.Min("radio.*.channel", 1),
+		parser.Max("radio.*.channel", 16),
+		parser.Length("radio.*.aliases", 1, 2),
+		parser.OneOf("radio.*", "channel", "frequency"),
+		parser.MutuallyExclusive("radio.*", "talkgroup", "frequency"),
+		parser.RequiredWith("radio.*", "encrypted", "key"),
+	}
+}
+
+type labelessRadioDef struct {
+	testRadioDef
+}
+
+func (t *labelessRadioDef) Rules() []*parser.Rule {
+	return []*parser.Rule{parser.Max("radio.channel", 16)}
+}
+
+func parseRadios(t *testing.T, src string) hcl.Diagnostics {
+	file, diags := hclsyntax.ParseConfig([]byte(src), "radios.hcl", hcl.Pos{Line: 1, Column: 1})
+	assert.False(t, diags.HasErrors())
+
+	reg := parser.NewRegistrar(1)
+	reg.RegisterBlock("radio", &testRadioDef{})
+
+	return reg.Parse(file.Body, &hcl.EvalContext{})
+}
+
+func TestValidator(tt *testing.T) {
+	tt.Run("valid configuration produces no diagnostics", func(t *testing.T) {
+		diags := parseRadios(t, `
+radio "r1" {
+  callsign  = "MEDIC12"
+  band      = "vhf"
+  channel   = 4
+  talkgroup = 100
+  encrypted = true
+  key       = "secret"
+  aliases   = ["m12"]
+}
+`)
+
+		assert.Len(t, diags, 0)
+	})
+
+	tt.Run("attribute rules report the range of the value", func(t *testing.T) {
+		diags := parseRadios(t, `
+radio "r1" {
+  callsign = "medic-12"
+  band     = "hf"
+  channel  = 20
+  aliases  = []
+}
+`)
+
+		assert.Len(t, diags, 4)
+
+		for _, diag := range diags {
+			assert.Equal(t, parser.DiagInvalidValue, diag.Summary)
+		}
+
+		assert.Equal(t, `The value of "callsign" must match the pattern ^[A-Z]+[0-9]+$.`, diags[0].Detail)
+		assert.Equal(t, 3, diags[0].Subject.Start.Line)
+		assert.Equal(t, 14, diags[0].Subject.Start.Column)
+		assert.Equal(t, 3, diags[0].Context.Start.Column)
+
+		assert.Equal(t, `The value of "band" must be one of "vhf", "uhf".`, diags[1].Detail)
+		assert.Equal(t, `The value of "channel" must be at most 16.`, diags[2].Detail)
+		assert.Equal(t, `The length of "aliases" must be at least 1.`, diags[3].Detail)
+	})
+
+	tt.Run("attribute rules check values computed from sensitive variables", func(t *testing.T) {
+		file, diags := hclsyntax.ParseConfig([]byte(`
+radio "r1" {
+  callsign = secrets.callsign
+  channel  = secrets.channel
+  aliases  = [secrets.callsign, secrets.callsign, secrets.callsign]
+}
+`), "radios.hcl", hcl.Pos{Line: 1, Column: 1})
+		assert.False(t, diags.HasErrors())
+
+		reg := parser.NewRegistrar(1)
+		reg.RegisterBlock("radio", &testRadioDef{})
+
+		diags = reg.Parse(file.Body, &hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				"secrets": cty.ObjectVal(map[string]cty.Value{
+					"callsign": cty.StringVal("medic-12"),
+					"channel":  cty.NumberIntVal(20),
+				}).Mark(parser.Sensitive),
+			},
+		})
+
+		assert.Len(t, diags, 3)
+
+		for _, diag := range diags {
+			assert.Equal(t, parser.DiagInvalidValue, diag.Summary)
+		}
+	})
+
+	tt.Run("block rules report the combination of attributes", func(t *testing.T) {
+		diags := parseRadios(t, `
+radio "r1" {
+  encrypted = true
+}
+
+radio "r2" {
+  channel   = 2
+  frequency = 155.34
+  talkgroup = 100
+}
+`)
+
+		details := []string{}
+		for _, diag := range diags {
+			assert.Equal(t, parser.DiagInvalidCombination, diag.Summary)
+			details = append(details, diag.Detail)
+		}
+
+		assert.ElementsMatch(t, []string{
+			`Exactly one of "channel", "frequency" must be set.`,
+			`Only one of "channel", "frequency" may be set but "frequency" is set along with "channel".`,
+			`Only one of "talkgroup", "frequency" may be set but "frequency" is set along with "talkgroup".`,
+			`The attribute "encrypted" requires "key" to be set.`,
+		}, details)
+	})
+
+	tt.Run("values that cannot be decoded are left to the spec", func(t *testing.T) {
+		diags := parseRadios(t, `
+radio "r1" {
+  channel = "two"
+}
+`)
+
+		assert.Len(t, diags, 1)
+		assert.Equal(t, "Incorrect attribute value type", diags[0].Summary)
+	})
+
+	tt.Run("Enum sets the values of the rule", func(t *testing.T) {
+		rule := parser.Enum("radio.*.band", cty.StringVal("vhf"))
+
+		assert.Equal(t, "radio.*", rule.Path)
+		assert.Equal(t, "band", rule.Attribute)
+		assert.Len(t, rule.Values, 1)
+	})
+
+	tt.Run("rules are not checked when the path omits labels", func(t *testing.T) {
+		reg := parser.NewRegistrar(1)
+		reg.RegisterBlock("radio", &labelessRadioDef{})
+
+		file, _ := hclsyntax.ParseConfig([]byte("radio \"r1\" {\n  channel = 20\n}\n"), "radios.hcl", hcl.Pos{Line: 1, Column: 1})
+
+		assert.Len(t, reg.Parse(file.Body, &hcl.EvalContext{}), 0)
+	})
+}
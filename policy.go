@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Policy overrides the severity of diagnostics. A Policy allows the same configuration to be
+// treated strictly in one environment, such as failing production deploys on deprecation
+// warnings, while only warning in another.
+//
+// Rules are matched in the order they were added and the last matching rule wins. When no
+// rule matches a diagnostic and WarningsAsErrors is true, warnings are promoted to errors.
+type Policy struct {
+	WarningsAsErrors bool
+	rules            []*policyRule
+}
+
+// policyRule changes the severity of diagnostics matching a code or summary pattern.
+type policyRule struct {
+	code     Code
+	pattern  *regexp.Regexp
+	severity hcl.DiagnosticSeverity
+}
+
+// NewPolicy creates a new Policy without any rules.
+func NewPolicy() *Policy {
+	return &Policy{
+		rules: make([]*policyRule, 0),
+	}
+}
+
+// WarningsAsErrorsPolicy creates a new Policy that promotes all warnings to errors.
+func WarningsAsErrorsPolicy() *Policy {
+	p := NewPolicy()
+	p.WarningsAsErrors = true
+
+	return p
+}
+
+// Promote promotes diagnostics with any of the given codes to errors.
+func (p *Policy) Promote(codes ...Code) *Policy {
+	return p.addCodes(hcl.DiagError, codes)
+}
+
+// PromoteMatching promotes diagnostics with a summary matching the pattern to errors.
+func (p *Policy) PromoteMatching(pattern *regexp.Regexp) *Policy {
+	return p.addPattern(hcl.DiagError, pattern)
+}
+
+// Demote demotes diagnostics with any of the given codes to warnings.
+func (p *Policy) Demote(codes ...Code) *Policy {
+	return p.addCodes(hcl.DiagWarning, codes)
+}
+
+// DemoteMatching demotes diagnostics with a summary matching the pattern to warnings.
+func (p *Policy) DemoteMatching(pattern *regexp.Regexp) *Policy {
+	return p.addPattern(hcl.DiagWarning, pattern)
+}
+
+func (p *Policy) addCodes(severity hcl.DiagnosticSeverity, codes []Code) *Policy {
+	for _, code := range codes {
+		p.rules = append(p.rules, &policyRule{code: code, severity: severity})
+	}
+
+	return p
+}
+
+func (p *Policy) addPattern(severity hcl.DiagnosticSeverity, pattern *regexp.Regexp) *Policy {
+	p.rules = append(p.rules, &policyRule{pattern: pattern, severity: severity})
+
+	return p
+}
+
+// Severity returns the severity the policy assigns to the diagnostic.
+func (p *Policy) Severity(diag *hcl.Diagnostic) hcl.DiagnosticSeverity {
+	severity := diag.Severity
+	matched := false
+
+	for _, rule := range p.rules {
+		if rule.matches(diag) {
+			severity = rule.severity
+			matched = true
+		}
+	}
+
+	if !matched && p.WarningsAsErrors && severity == hcl.DiagWarning {
+		severity = hcl.DiagError
+	}
+
+	return severity
+}
+
+// Apply returns the diagnostics with the policy applied. Diagnostics that change severity
+// are copied so the originals are never modified.
+func (p *Policy) Apply(diags hcl.Diagnostics) hcl.Diagnostics {
+	res := make(hcl.Diagnostics, 0, len(diags))
+
+	for _, diag := range diags {
+		if severity := p.Severity(diag); severity != diag.Severity {
+			cp := *diag
+			cp.Severity = severity
+			diag = &cp
+		}
+
+		res = append(res, diag)
+	}
+
+	return res
+}
+
+func (r *policyRule) matches(diag *hcl.Diagnostic) bool {
+	if r.pattern != nil {
+		return r.pattern.MatchString(diag.Summary)
+	}
+
+	return r.code != "" && CodeOf(diag) == r.code
+}
+
+// WithPolicy returns a new Diagnostics with the severity of each diagnostic overridden by the
+// given Policy. HasErrors, Errors, Warnings and all writers respect the resulting severities.
+func (d *Diagnostics) WithPolicy(p *Policy) *Diagnostics {
+	return d.derive(p.Apply(d.Diags))
+}
+
+// UsePolicy sets the Policy applied to all Diagnostics returned by the Spec. Passing nil
+// removes the policy.
+func (s *Spec) UsePolicy(p *Policy) {
+	s.policy = p
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(tt *testing.T) {
+	deprecated := &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Deprecated attribute",
+		Detail:   "The attribute \"callsign\" is deprecated.",
+	}
+	missing := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Missing required argument",
+	}
+	other := &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Something else",
+	}
+	diags := &spec.Diagnostics{
+		Spec:  spec.NewSubset(),
+		Diags: hcl.Diagnostics{deprecated, missing, other},
+	}
+
+	tt.Run("PromoteMatching() promotes warnings matching the pattern", func(t *testing.T) {
+		res := diags.Warnings().WithPolicy(spec.NewPolicy().PromoteMatching(regexp.MustCompile(`^Deprecated`)))
+
+		assert.True(t, res.HasErrors())
+		assert.Equal(t, 1, res.Errors().Len())
+		assert.Equal(t, hcl.DiagWarning, deprecated.Severity, "the original diagnostic must not be modified")
+	})
+
+	tt.Run("Demote() demotes errors by code", func(t *testing.T) {
+		res := diags.WithPolicy(spec.NewPolicy().Demote(spec.CodeMissingRequiredArgument))
+
+		assert.False(t, res.HasErrors())
+	})
+
+	tt.Run("WarningsAsErrors promotes warnings not matched by a rule", func(t *testing.T) {
+		p := spec.WarningsAsErrorsPolicy().DemoteMatching(regexp.MustCompile(`^Something`))
+		res := diags.WithPolicy(p)
+
+		assert.Equal(t, 2, res.Errors().Len())
+		assert.Equal(t, "Something else", res.Warnings().Raw()[0].Summary)
+	})
+
+	tt.Run("renderers respect the policy", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		res := diags.WithPolicy(spec.WarningsAsErrorsPolicy())
+
+		assert.NoError(t, res.WriteText(b, 0, false))
+		assert.Contains(t, b.String(), "Error: Deprecated attribute")
+	})
+
+	tt.Run("UsePolicy() applies the policy to diagnostics returned by the Spec", func(t *testing.T) {
+		s := spec.NewSubset()
+		s.UsePolicy(spec.NewPolicy().Demote(spec.CodeCannotDetermineFileType))
+
+		res := s.Files("./testdata/unknown.txt")
+		assert.False(t, res.HasErrors())
+		assert.Equal(t, 1, res.Warnings().Len())
+	})
+}
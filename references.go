@@ -0,0 +1,346 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// diagnostic messages
+const (
+	DiagUnknownReference         = "Reference to unknown block"
+	DiagUnknownReferenceDetail   = "No %s block has the label %q."
+	DiagUnknownReferenceSuggest  = " Did you mean %q?"
+	DiagDuplicateReference       = "Duplicate reference target"
+	DiagDuplicateReferenceDetail = "The label %q of this %s block is also used by the %s block at %s. Blocks referred to by label must use unique labels."
+)
+
+// referenceBody is a body found at the path of a parser.Reference.
+type referenceBody struct {
+	body   hcl.Body
+	schema *parser.SchemaBody
+	block  *hcl.Block
+	parent *hcl.Block
+}
+
+// referenceTarget is a block that may be referred to by its label.
+type referenceTarget struct {
+	label  string
+	block  *hcl.Block
+	parent *hcl.Block
+}
+
+// references checks the parser.Reference's of the registered BlockDefinition's, returning an error
+// for each label that does not refer to a block and for each target block whose label is already
+// used by another block at the same path.
+//
+// Duplicate labels within the same body are reported while decoding, so only the blocks within
+// different enclosing blocks are reported here.
+func (s *Spec) references() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	checked := map[string]bool{}
+
+	for _, ref := range s.allReferences() {
+		targets := s.referenceTargets(ref.Target)
+
+		if !checked[ref.Target] {
+			checked[ref.Target] = true
+			diags = diags.Extend(duplicateTargets(ref.Target, targets))
+		}
+
+		diags = diags.Extend(s.checkReference(ref, targets))
+	}
+
+	return diags
+}
+
+// allReferences returns the parser.Reference's of all registrations in the order they are parsed.
+func (s *Spec) allReferences() []*parser.Reference {
+	res := []*parser.Reference{}
+
+	for _, reg := range s.orderedRegistrations() {
+		if r, ok := reg.Definition.(parser.Referencer); ok {
+			res = append(res, r.References()...)
+		}
+	}
+
+	return res
+}
+
+// referenceBodies returns the bodies of all blocks at the path, such as "station" or "unit.*",
+// or the root bodies of the files for an empty path. The wildcards for the labels of the last
+// block may be omitted. Nil is returned when the path is not described by the spec.
+func (s *Spec) referenceBodies(path string) []*referenceBody {
+	schema := parser.Introspect(s.Build())
+	bodies := []*referenceBody{}
+
+	for _, filename := range s.filenames() {
+		bodies = append(bodies, &referenceBody{body: s.migratedFile(filename).Body, schema: schema})
+	}
+
+	segments := []string{}
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+
+	for len(segments) > 0 {
+		typeName := segments[0]
+		segments = segments[1:]
+
+		sb, ok := schema.Blocks[typeName]
+		if !ok || sb.Body == nil {
+			return nil
+		}
+
+		// the labels of the last block may be omitted, as in the path of a block
+		for i := 0; i < len(sb.LabelNames) && len(segments) > 0; i++ {
+			if segments[0] != parser.SchemaWildcard {
+				return nil
+			}
+
+			segments = segments[1:]
+		}
+
+		next := []*referenceBody{}
+
+		for _, body := range bodies {
+			content, _, _ := body.body.PartialContent(hcldec.ImpliedSchema(schema.Spec))
+			if content == nil {
+				continue
+			}
+
+			for _, block := range content.Blocks {
+				if block.Type == typeName {
+					next = append(next, &referenceBody{body: block.Body, schema: sb.Body, block: block, parent: body.block})
+				}
+			}
+		}
+
+		schema = sb.Body
+		bodies = next
+	}
+
+	return bodies
+}
+
+// referenceTargets returns the labeled blocks at the target path, ordered by filename.
+func (s *Spec) referenceTargets(target string) []*referenceTarget {
+	res := []*referenceTarget{}
+
+	for _, body := range s.referenceBodies(target) {
+		if len(body.block.Labels) > 0 {
+			res = append(res, &referenceTarget{label: body.block.Labels[0], block: body.block, parent: body.parent})
+		}
+	}
+
+	return res
+}
+
+// referenceLabels returns the sorted, unique labels of the targets.
+func referenceLabels(targets []*referenceTarget) []string {
+	seen := map[string]bool{}
+	res := []string{}
+
+	for _, target := range targets {
+		if !seen[target.label] {
+			seen[target.label] = true
+			res = append(res, target.label)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// duplicateTargets returns an error for each target whose label is used by a previous target
+// within a different enclosing block.
+func duplicateTargets(path string, targets []*referenceTarget) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	first := map[string]*referenceTarget{}
+
+	for _, target := range targets {
+		prev, ok := first[target.label]
+		if !ok {
+			first[target.label] = target
+			continue
+		}
+
+		if prev.parent == target.parent {
+			continue
+		}
+
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  DiagDuplicateReference,
+			Detail:   fmt.Sprintf(DiagDuplicateReferenceDetail, target.label, path, path, prev.block.DefRange),
+			Subject:  target.block.LabelRanges[0].Ptr(),
+			Context:  target.block.DefRange.Ptr(),
+		})
+	}
+
+	return diags
+}
+
+// checkReference returns an error for each label within the attributes of the reference that
+// does not refer to one of the targets.
+func (s *Spec) checkReference(ref *parser.Reference, targets []*referenceTarget) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+	labels := referenceLabels(targets)
+
+	known := map[string]bool{}
+	for _, label := range labels {
+		known[label] = true
+	}
+
+	bodyPath, name := "", ref.Attribute
+	if i := strings.LastIndex(ref.Attribute, "."); i >= 0 {
+		bodyPath, name = ref.Attribute[:i], ref.Attribute[i+1:]
+	}
+
+	for _, body := range s.referenceBodies(bodyPath) {
+		content, _, _ := body.body.PartialContent(hcldec.ImpliedSchema(body.schema.Spec))
+		if content == nil || content.Attributes[name] == nil {
+			continue
+		}
+
+		attr := content.Attributes[name]
+
+		for _, label := range s.referenceValues(attr) {
+			if known[label.value] {
+				continue
+			}
+
+			detail := fmt.Sprintf(DiagUnknownReferenceDetail, ref.Target, label.value)
+			if suggestion := suggestLabel(label.value, labels); suggestion != "" {
+				detail += fmt.Sprintf(DiagUnknownReferenceSuggest, suggestion)
+			}
+
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  DiagUnknownReference,
+				Detail:   detail,
+				Subject:  label.rng.Ptr(),
+				Context:  attr.Range.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// referenceValue is a single label within the value of a referencing attribute.
+type referenceValue struct {
+	value string
+	rng   hcl.Range
+}
+
+// referenceValues returns the known labels within the value of the attribute. Each label uses the
+// range of its own expression when the attribute is written as a tuple.
+func (s *Spec) referenceValues(attr *hcl.Attribute) []*referenceValue {
+	res := []*referenceValue{}
+
+	val, diags := attr.Expr.Value(s.ctx)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() {
+		return res
+	}
+
+	if !val.CanIterateElements() || val.Type().IsMapType() || val.Type().IsObjectType() {
+		if label, err := convert.Convert(val, cty.String); err == nil && label.IsKnown() && !label.IsNull() {
+			res = append(res, &referenceValue{value: label.AsString(), rng: attr.Expr.Range()})
+		}
+
+		return res
+	}
+
+	var exprs []hclsyntax.Expression
+	if tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok && len(tuple.Exprs) == val.LengthInt() {
+		exprs = tuple.Exprs
+	}
+
+	i := 0
+	for it := val.ElementIterator(); it.Next(); i++ {
+		_, elem := it.Element()
+
+		label, err := convert.Convert(elem, cty.String)
+		if err != nil || !label.IsKnown() || label.IsNull() {
+			continue
+		}
+
+		rng := attr.Expr.Range()
+		if exprs != nil {
+			rng = exprs[i].Range()
+		}
+
+		res = append(res, &referenceValue{value: label.AsString(), rng: rng})
+	}
+
+	return res
+}
+
+// suggestLabel returns the sorted label closest to the value, or an empty string when none of
+// them is close enough to be a likely typo.
+func suggestLabel(value string, labels []string) string {
+	best, bestDistance := "", len([]rune(value))/2+1
+
+	for _, label := range labels {
+		if d := levenshtein(value, label); d < bestDistance {
+			best, bestDistance = label, d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the number of single character insertions, deletions and substitutions
+// needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	res := values[0]
+	for _, v := range values[1:] {
+		if v < res {
+			res = v
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type assignmentSchema struct{}
+
+func (s *assignmentSchema) Name() string {
+	return "assignment"
+}
+
+func (s *assignmentSchema) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "assignment",
+		LabelNames: []string{"callsign"},
+		Nested: hcldec.ObjectSpec{
+			"station": &hcldec.AttrSpec{Name: "station", Type: cty.String, Required: true},
+			"backup":  &hcldec.AttrSpec{Name: "backup", Type: cty.List(cty.String)},
+		},
+	}
+}
+
+func (s *assignmentSchema) References() []*parser.Reference {
+	return []*parser.Reference{
+		{Attribute: "assignment.*.station", Target: "station"},
+		{Attribute: "assignment.*.backup", Target: "station"},
+	}
+}
+
+type agencySchema struct{}
+
+func (s *agencySchema) Name() string {
+	return "agency"
+}
+
+func (s *agencySchema) Spec() hcldec.Spec {
+	return &hcldec.BlockMapSpec{
+		TypeName:   "agency",
+		LabelNames: []string{"id"},
+		Nested: hcldec.ObjectSpec{
+			"station": &hcldec.BlockMapSpec{
+				TypeName:   "station",
+				LabelNames: []string{"id"},
+				Nested: hcldec.ObjectSpec{
+					"name": &hcldec.AttrSpec{Name: "name", Type: cty.String},
+				},
+			},
+			"dispatch": &hcldec.AttrSpec{Name: "dispatch", Type: cty.String},
+		},
+	}
+}
+
+func (s *agencySchema) References() []*parser.Reference {
+	return []*parser.Reference{
+		{Attribute: "agency.*.dispatch", Target: "agency.*.station"},
+	}
+}
+
+const referencedStations = `station "st12" {
+  name = "Station 12"
+}
+
+station "st13" {
+  name = "Station 13"
+}
+`
+
+func TestReferences(tt *testing.T) {
+	tt.Run("Parse() accepts references to existing blocks", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{}, &assignmentSchema{})
+		s.ParseSource([]byte(`assignment "medic12" {
+  station = "st12"
+  backup  = ["st13"]
+}
+`), "assignments.hcl")
+
+		s.ParseSource([]byte(referencedStations), "stations.hcl")
+		assert.Equal(t, 0, s.Parse(&hcl.EvalContext{}).Len())
+	})
+
+	tt.Run("Parse() reports dangling references with suggestions", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{}, &assignmentSchema{})
+		s.ParseSource([]byte(`assignment "medic12" {
+  station = "st21"
+  backup  = ["st13", "hq"]
+}
+`), "assignments.hcl")
+
+		s.ParseSource([]byte(referencedStations), "stations.hcl")
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.Equal(t, []spec.Code{spec.CodeUnknownReference, spec.CodeUnknownReference}, diagnosticCodes(diags))
+
+		raw := diags.Raw()
+		assert.Equal(t, `No station block has the label "st21". Did you mean "st12"?`, raw[0].Detail)
+		assert.Equal(t, 2, raw[0].Subject.Start.Line)
+		assert.Equal(t, 13, raw[0].Subject.Start.Column)
+
+		assert.Equal(t, `No station block has the label "hq".`, raw[1].Detail)
+		assert.Equal(t, 3, raw[1].Subject.Start.Line)
+		assert.Equal(t, 22, raw[1].Subject.Start.Column)
+	})
+
+	tt.Run("Parse() reports duplicate labels of nested targets across files", func(t *testing.T) {
+		s := spec.NewSubset(&agencySchema{})
+		s.ParseSource([]byte(`agency "county" {
+  dispatch = "central"
+
+  station "central" {}
+}
+`), "county.hcl")
+		s.ParseSource([]byte(`agency "city" {
+  dispatch = "centrl"
+
+  station "central" {}
+}
+`), "city.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.Equal(t, []spec.Code{spec.CodeDuplicateReference, spec.CodeUnknownReference}, diagnosticCodes(diags))
+
+		raw := diags.Raw()
+		assert.Equal(t, "county.hcl", raw[0].Subject.Filename)
+		assert.Equal(t, `The label "central" of this agency.*.station block is also used by the agency.*.station block at city.hcl:4,3-20. Blocks referred to by label must use unique labels.`, raw[0].Detail)
+		assert.Equal(t, `No agency.*.station block has the label "centrl". Did you mean "central"?`, raw[1].Detail)
+	})
+
+	tt.Run("Complete() lists the labels of the referenced blocks", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{}, &assignmentSchema{})
+		s.ParseSource([]byte("assignment \"medic12\" {\n  station = \"st\"\n}\n"), "assignments.hcl")
+
+		s.ParseSource([]byte(referencedStations), "stations.hcl")
+		s.Parse(&hcl.EvalContext{})
+
+		res := s.Complete("assignments.hcl", hcl.Pos{Line: 2, Column: 13})
+
+		assert.Subset(t, candidateLabels(res), []string{`"st12"`, `"st13"`})
+	})
+}
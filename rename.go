@@ -0,0 +1,341 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// diagnostic messages
+const (
+	DiagCannotRename          = "Cannot rename"
+	DiagCannotRenameDetail    = "Only the labels of blocks, or references to the values injected from them, can be renamed."
+	DiagCannotRenameNotParsed = "The file %q has not been parsed."
+	DiagCannotRenameExists    = "A %s block labeled %q already exists."
+	DiagInvalidName           = "Invalid name"
+	DiagInvalidNameDetail     = "The name %q is not a valid identifier. Names must start with a letter and may contain letters, digits, underscores and dashes."
+	DiagFileNotEditable       = "File cannot be edited"
+	DiagFileNotEditableDetail = "The file could not be parsed for editing and has not been changed."
+)
+
+// renameTarget is the block label being renamed.
+type renameTarget struct {
+	filename string
+	blocks   []*hcl.Block
+	label    int
+
+	// search is the prefix of the traversals referring to the block or nil when the block is
+	// not injected as a variable.
+	search []string
+}
+
+// Rename renames the block label at the given position, or the label of the block defining the
+// injected value referenced at the position, to newName. The returned ChangeSet contains the
+// declaration along with every traversal referring to it across all parsed HCL files, rewritten
+// using hclwrite so formatting and comments are kept. For example, renaming the "st12" label of
+// a station block also rewrites each "station.st12" traversal.
+//
+// Only traversals using attribute access, such as station.st12, are rewritten. References within
+// JSON files are not rewritten though a JSON declaration is. Nil is returned along with error
+// diagnostics when nothing can be renamed at the position.
+func (s *Spec) Rename(filename string, pos hcl.Pos, newName string) (*ChangeSet, *Diagnostics) {
+	if !hclsyntax.ValidIdentifier(newName) {
+		return nil, s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagInvalidName,
+				Detail:   fmt.Sprintf(DiagInvalidNameDetail, newName),
+			},
+		})
+	}
+
+	target, diags := s.renameTarget(filename, pos)
+	if diags.HasErrors() {
+		return nil, s.diagnostics(diags)
+	}
+
+	if block := target.blocks[len(target.blocks)-1]; block.Labels[target.label] != newName && s.labelExists(target, newName) {
+		return nil, s.diagnostics(hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotRename,
+				Detail:   fmt.Sprintf(DiagCannotRenameExists, block.Type, newName),
+				Subject:  block.LabelRanges[target.label].Ptr(),
+			},
+		})
+	}
+
+	changes := newChangeSet()
+
+	for _, name := range s.filenames() {
+		file := s.files[name]
+
+		var src []byte
+
+		switch {
+		case isHCLBody(file.Body):
+			var fileDiags hcl.Diagnostics
+			src, fileDiags = target.rewriteHCL(name, file.Bytes, newName)
+			diags = diags.Extend(fileDiags)
+		case name == target.filename:
+			src = target.rewriteJSON(file.Bytes, newName)
+		}
+
+		if src != nil && !bytes.Equal(src, file.Bytes) {
+			changes.files[name] = src
+		}
+	}
+
+	return changes, s.diagnostics(diags)
+}
+
+// renameTarget finds the block label to rename at the position.
+func (s *Spec) renameTarget(filename string, pos hcl.Pos) (*renameTarget, hcl.Diagnostics) {
+	loc := s.At(filename, pos)
+	if loc == nil {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotRename,
+				Detail:   fmt.Sprintf(DiagCannotRenameNotParsed, filename),
+			},
+		}
+	}
+
+	cannotRename := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  DiagCannotRename,
+			Detail:   DiagCannotRenameDetail,
+			Subject:  &hcl.Range{Filename: filename, Start: loc.Pos, End: loc.Pos},
+		},
+	}
+
+	// a reference is renamed by renaming the innermost labeled block of its definition
+	if loc.Traversal != nil {
+		def := s.Definition(loc.Traversal)
+		if def == nil || len(def.Path) < 2 {
+			return nil, cannotRename
+		}
+
+		decl := s.At(def.Range.Filename, def.Range.Start)
+		if decl == nil {
+			return nil, cannotRename
+		}
+
+		for i := len(decl.Blocks) - 1; i >= 0; i-- {
+			block := decl.Blocks[i]
+
+			bdef := s.blockDefinition(block)
+			if len(block.Labels) == 0 || bdef == nil || !hasPathPrefix(def.Path, bdef.Path) {
+				continue
+			}
+
+			return &renameTarget{
+				filename: def.Range.Filename,
+				blocks:   decl.Blocks[:i+1],
+				label:    len(block.Labels) - 1,
+				search:   bdef.Path,
+			}, nil
+		}
+
+		return nil, cannotRename
+	}
+
+	block := loc.Block()
+	if block == nil || len(block.Labels) == 0 {
+		return nil, cannotRename
+	}
+
+	target := &renameTarget{
+		filename: filename,
+		blocks:   loc.Blocks,
+		label:    len(block.Labels) - 1,
+	}
+
+	onHeader := containsPos(block.TypeRange, loc.Pos)
+
+	for i, rng := range block.LabelRanges {
+		if containsPos(rng, loc.Pos) {
+			target.label = i
+			onHeader = true
+		}
+	}
+
+	if !onHeader {
+		return nil, cannotRename
+	}
+
+	if bdef := s.blockDefinition(block); bdef != nil {
+		target.search = bdef.Path[:len(bdef.Path)-len(block.Labels)+target.label+1]
+	}
+
+	return target, nil
+}
+
+// labelExists returns true when a block at the same path as the target, within any of the parsed
+// files, already uses newName in place of the renamed label.
+func (s *Spec) labelExists(target *renameTarget, newName string) bool {
+	last := len(target.blocks) - 1
+
+	want := append([]string{}, target.blocks[last].Labels...)
+	want[target.label] = newName
+
+	for _, filename := range s.filenames() {
+		bodies := []hcl.Body{s.migratedFile(filename).Body}
+
+		for i, chained := range target.blocks {
+			labels := chained.Labels
+			if i == last {
+				labels = want
+			}
+
+			schema := &hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{{Type: chained.Type, LabelNames: make([]string, len(labels))}},
+			}
+
+			next := []hcl.Body{}
+
+			for _, body := range bodies {
+				content, _, _ := body.PartialContent(schema)
+				if content == nil {
+					continue
+				}
+
+				for _, block := range content.Blocks {
+					if equalStrings(block.Labels, labels) {
+						next = append(next, block.Body)
+					}
+				}
+			}
+
+			bodies = next
+		}
+
+		if len(bodies) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockDefinition returns the definition of the value injected from the block or nil when the
+// block is not injected.
+func (s *Spec) blockDefinition(block *hcl.Block) *Definition {
+	rng := blockRange(block)
+
+	for _, def := range s.definitions {
+		if len(def.Path) > 1 && def.Range == rng {
+			return def
+		}
+	}
+
+	return nil
+}
+
+// rewriteHCL renames the declaration, when within the file, and all references within the
+// HCL source.
+func (t *renameTarget) rewriteHCL(filename string, src []byte, newName string) ([]byte, hcl.Diagnostics) {
+	file, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagWarning,
+				Summary:  DiagFileNotEditable,
+				Detail:   DiagFileNotEditableDetail,
+				Subject:  &hcl.Range{Filename: filename, Start: hcl.InitialPos, End: hcl.InitialPos},
+			},
+		}
+	}
+
+	if filename == t.filename {
+		if block := findWriteBlock(file.Body(), t.blocks); block != nil {
+			labels := block.Labels()
+			labels[t.label] = newName
+			block.SetLabels(labels)
+		}
+	}
+
+	if t.search != nil {
+		replacement := append([]string{}, t.search...)
+		replacement[len(replacement)-1] = newName
+
+		renameReferences(file.Body(), t.search, replacement)
+	}
+
+	return file.Bytes(), nil
+}
+
+// rewriteJSON renames the declaration within the JSON source.
+func (t *renameTarget) rewriteJSON(src []byte, newName string) []byte {
+	rng := t.blocks[len(t.blocks)-1].LabelRanges[t.label]
+
+	res := append([]byte{}, src[:rng.Start.Byte]...)
+	res = append(res, fmt.Sprintf("%q", newName)...)
+
+	return append(res, src[rng.End.Byte:]...)
+}
+
+// findWriteBlock follows the chain of blocks, outermost first, within the body.
+func findWriteBlock(body *hclwrite.Body, chain []*hcl.Block) *hclwrite.Block {
+	var res *hclwrite.Block
+
+	for _, want := range chain {
+		res = nil
+
+		for _, block := range body.Blocks() {
+			if block.Type() == want.Type && equalStrings(block.Labels(), want.Labels) {
+				res = block
+				break
+			}
+		}
+
+		if res == nil {
+			return nil
+		}
+
+		body = res.Body()
+	}
+
+	return res
+}
+
+// renameReferences renames the prefix of all matching traversals within the body.
+func renameReferences(body *hclwrite.Body, search, replacement []string) {
+	for _, attr := range body.Attributes() {
+		attr.Expr().RenameVariablePrefix(search, replacement)
+	}
+
+	for _, block := range body.Blocks() {
+		renameReferences(block.Body(), search, replacement)
+	}
+}
+
+func isHCLBody(body hcl.Body) bool {
+	_, ok := body.(*hclsyntax.Body)
+	return ok
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
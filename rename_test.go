@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRename(tt *testing.T) {
+	const hclFile = "./testdata/stations/stations.hcl"
+	const jsonFile = "./testdata/stations/stations.json"
+
+	s := spec.NewSubset(&stationSchema{})
+	assert.False(tt, s.Files(hclFile, jsonFile).HasErrors())
+	s.Parse(&hcl.EvalContext{})
+
+	tt.Run("Rename() rejects names that are not identifiers", func(t *testing.T) {
+		changes, diags := s.Rename(hclFile, hcl.Pos{Line: 10, Column: 10}, "station 99")
+
+		assert.Nil(t, changes)
+		assert.Equal(t, spec.CodeInvalidName, spec.CodeOf(diags.Raw()[0]))
+	})
+
+	tt.Run("Rename() requires a block label or reference", func(t *testing.T) {
+		changes, diags := s.Rename(hclFile, hcl.Pos{Line: 2, Column: 5}, "st99")
+		assert.Nil(t, changes)
+		assert.Equal(t, spec.CodeCannotRename, spec.CodeOf(diags.Raw()[0]))
+
+		changes, diags = s.Rename("./testdata/stations/missing.hcl", hcl.Pos{Line: 1, Column: 1}, "st99")
+		assert.Nil(t, changes)
+		assert.True(t, diags.HasErrors())
+	})
+
+	tt.Run("Rename() rejects labels that are already used", func(t *testing.T) {
+		changes, diags := s.Rename(hclFile, hcl.Pos{Line: 10, Column: 10}, "st12")
+		assert.Nil(t, changes)
+		assert.Equal(t, spec.CodeCannotRename, spec.CodeOf(diags.Raw()[0]))
+		assert.Equal(t, `A station block labeled "st12" already exists.`, diags.Raw()[0].Detail)
+
+		changes, diags = s.Rename(hclFile, hcl.Pos{Line: 10, Column: 10}, "st14")
+		assert.Nil(t, changes)
+		assert.True(t, diags.HasErrors())
+	})
+
+	tt.Run("Rename() renames a block label and its references", func(t *testing.T) {
+		changes, diags := s.Rename(hclFile, hcl.Pos{Line: 10, Column: 10}, "st99")
+
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, []string{hclFile}, changes.Filenames())
+
+		src := string(changes.Bytes(hclFile))
+		assert.Contains(t, src, "station \"st99\" {\n  name    = \"Station 13\"")
+		assert.Contains(t, src, `upper("medic-${station.st99.channel}")`)
+		assert.NotContains(t, src, "st13")
+	})
+
+	tt.Run("Rename() renames the block defining a reference", func(t *testing.T) {
+		changes, diags := s.Rename(hclFile, hcl.Pos{Line: 6, Column: 42}, "st99")
+
+		assert.False(t, diags.HasErrors())
+		assert.Contains(t, string(changes.Bytes(hclFile)), `station "st99" {`)
+		assert.Contains(t, string(changes.Bytes(hclFile)), `station.st99.channel`)
+	})
+
+	tt.Run("Rename() renames block labels within JSON files", func(t *testing.T) {
+		changes, diags := s.Rename(jsonFile, hcl.Pos{Line: 3, Column: 7}, "st99")
+
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, []string{jsonFile}, changes.Filenames())
+		assert.Contains(t, string(changes.Bytes(jsonFile)), `"st99": {`)
+	})
+
+	tt.Run("Apply() writes the changes in place", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "spec")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		src, err := ioutil.ReadFile(hclFile)
+		assert.NoError(t, err)
+
+		filename := filepath.Join(dir, "stations.hcl")
+		assert.NoError(t, ioutil.WriteFile(filename, src, 0640))
+
+		s := spec.NewSubset(&stationSchema{})
+		s.Files(filename)
+		s.Parse(&hcl.EvalContext{})
+
+		changes, diags := s.Rename(filename, hcl.Pos{Line: 10, Column: 10}, "st99")
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, 1, changes.Len())
+		assert.NoError(t, changes.Apply())
+
+		res, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.ReplaceAll(string(src), "st13", "st99"), string(res))
+
+		info, err := os.Stat(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+}
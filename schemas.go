@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"sort"
+
+	"github.com/responserms/spec/parser"
+)
+
+// schemas holds the named schemas registered with RegisterSchema.
+var schemas = map[string]parser.NamedBlockDefinitions{}
+
+// RegisterSchema registers the pre-ordered parser.NamedBlockDefinitions under the given name
+// allowing tools such as the spec command-line tool and language server to resolve a schema by
+// name. Registering a schema with an existing name replaces it. RegisterSchema is not safe for
+// concurrent use and should be called while setting up the application.
+func RegisterSchema(name string, defs parser.NamedBlockDefinitions) {
+	schemas[name] = defs
+}
+
+// Schema returns the parser.NamedBlockDefinitions registered under the given name.
+func Schema(name string) (parser.NamedBlockDefinitions, bool) {
+	defs, ok := schemas[name]
+	return defs, ok
+}
+
+// SchemaNames returns the names of all registered schemas in alphabetical order.
+func SchemaNames() []string {
+	names := make([]string, 0, len(schemas))
+
+	for name := range schemas {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
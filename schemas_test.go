@@ -0,0 +1,31 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaRegistry(tt *testing.T) {
+	tt.Run("RegisterSchema() makes the schema available by name", func(t *testing.T) {
+		spec.RegisterSchema("registry-test", schemaList)
+
+		defs, ok := spec.Schema("registry-test")
+		assert.True(t, ok)
+		assert.Len(t, defs, len(schemaList))
+		assert.Contains(t, spec.SchemaNames(), "registry-test")
+	})
+
+	tt.Run("Schema() returns false for unknown schemas", func(t *testing.T) {
+		_, ok := spec.Schema("does-not-exist")
+
+		assert.False(t, ok)
+	})
+}
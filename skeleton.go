@@ -0,0 +1,305 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Skeleton returns a starting configuration file in the given Syntax for the registered
+// BlockDefinition's, in the order they are parsed. Required blocks and attributes are included
+// with a placeholder value of their type, or their default, along with their description from
+// the parser.Metadata of their registration. Blocks are written with their label names as
+// placeholder labels.
+//
+// Within HCL optional blocks and attributes are included but commented out, while deprecated
+// optional ones are left out. As JSON does not support comments, JSON files only include the
+// required blocks and attributes and describe the rest using the "//" comment property of each
+// object. An error is returned for an unknown Syntax.
+func (s *Spec) Skeleton(syntax Syntax) ([]byte, error) {
+	switch syntax {
+	case SyntaxHCL:
+		return s.skeletonHCL(), nil
+	case SyntaxJSON:
+		return s.skeletonJSON()
+	default:
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  DiagCannotDetermineFileType,
+				Detail:   DiagCannotDetermineFileTypeDetail,
+			},
+		}
+	}
+}
+
+func (s *Spec) skeletonHCL() []byte {
+	lines := []string{}
+
+	for _, reg := range s.orderedRegistrations() {
+		body := s.skeletonBody(reg.Schema(), false)
+		if len(body) == 0 {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, body...)
+	}
+
+	return hclwrite.Format(append(s.versionHeader(), strings.Join(lines, "\n")+"\n"...))
+}
+
+// skeletonBody returns the lines of the body. Commented is true when the enclosing block is
+// already commented out.
+func (s *Spec) skeletonBody(body *parser.SchemaBody, commented bool) []string {
+	lines := []string{}
+
+	for _, name := range body.AttributeNames() {
+		attr := body.Attributes[name]
+		doc := s.Documentation(attr.Path)
+
+		if !attr.Required && doc != nil && doc.Deprecation != "" {
+			continue
+		}
+
+		comment := skeletonComment(doc)
+		if len(comment) > 0 && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		line := fmt.Sprintf("%s = %s", name, hclwrite.TokensForValue(skeletonValue(attr.Type, attr.Default)).Bytes())
+		if !attr.Required && !commented {
+			line = "# " + line
+		}
+
+		lines = append(append(lines, comment...), line)
+	}
+
+	for _, name := range body.BlockTypes() {
+		block := body.Blocks[name]
+		doc := s.Documentation(block.Path)
+
+		if !block.Required && doc != nil && doc.Deprecation != "" {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, skeletonComment(doc)...)
+
+		header := name
+		for _, label := range block.LabelNames {
+			header += fmt.Sprintf(" %q", label)
+		}
+
+		blockLines := []string{header + " {"}
+
+		if block.Body != nil {
+			for _, line := range s.skeletonBody(block.Body, commented || !block.Required) {
+				if line != "" {
+					line = "  " + line
+				}
+
+				blockLines = append(blockLines, line)
+			}
+		}
+
+		blockLines = append(blockLines, "}")
+
+		for _, line := range blockLines {
+			if !block.Required && !commented {
+				line = strings.TrimRight("# "+line, " ")
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func (s *Spec) skeletonJSON() ([]byte, error) {
+	res := map[string]interface{}{}
+	optional := []string{}
+
+	for _, reg := range s.orderedRegistrations() {
+		body, regOptional, err := s.skeletonJSONBody(reg.Schema())
+		if err != nil {
+			return nil, err
+		}
+
+		for name, val := range body {
+			res[name] = val
+		}
+
+		optional = append(optional, regOptional...)
+	}
+
+	if len(optional) > 0 {
+		res[jsonSchemaComment] = skeletonJSONComment(nil, optional)
+	}
+
+	if s.versioned() {
+		res[VersionAttribute] = s.Version()
+	}
+
+	src, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(src, '\n'), nil
+}
+
+// skeletonJSONBody returns the properties of the required contents of the body along with the
+// names of its optional contents.
+func (s *Spec) skeletonJSONBody(body *parser.SchemaBody) (map[string]interface{}, []string, error) {
+	res := map[string]interface{}{}
+	optional := []string{}
+
+	for _, name := range body.AttributeNames() {
+		attr := body.Attributes[name]
+		if !attr.Required {
+			optional = append(optional, name)
+			continue
+		}
+
+		val := skeletonValue(attr.Type, attr.Default)
+
+		src, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res[name] = json.RawMessage(src)
+	}
+
+	for _, name := range body.BlockTypes() {
+		block := body.Blocks[name]
+		if !block.Required {
+			optional = append(optional, name)
+			continue
+		}
+
+		var val interface{} = map[string]interface{}{}
+
+		if block.Body != nil {
+			nested, nestedOptional, err := s.skeletonJSONBody(block.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if comment := skeletonJSONComment(s.Documentation(block.Path), nestedOptional); comment != "" {
+				nested[jsonSchemaComment] = comment
+			}
+
+			val = nested
+		}
+
+		for i := len(block.LabelNames) - 1; i >= 0; i-- {
+			val = map[string]interface{}{block.LabelNames[i]: val}
+		}
+
+		res[name] = val
+	}
+
+	return res, optional, nil
+}
+
+// skeletonJSONComment returns the comment describing a block and listing its optional contents.
+func skeletonJSONComment(doc *parser.AttributeMetadata, optional []string) string {
+	comment := []string{}
+
+	if doc != nil && doc.Description != "" {
+		comment = append(comment, doc.Description)
+	}
+
+	if len(optional) > 0 {
+		comment = append(comment, fmt.Sprintf("Optional: %s.", strings.Join(optional, ", ")))
+	}
+
+	return strings.Join(comment, " ")
+}
+
+// skeletonComment returns the comment lines describing a block or attribute.
+func skeletonComment(doc *parser.AttributeMetadata) []string {
+	if doc == nil || doc.Description == "" {
+		return nil
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(doc.Description), "\n") {
+		lines = append(lines, strings.TrimRight("# "+strings.TrimSpace(line), " "))
+	}
+
+	return lines
+}
+
+// skeletonValue returns the default value, when known, or a placeholder value of the type.
+func skeletonValue(ty cty.Type, def cty.Value) cty.Value {
+	if def != cty.NilVal && def.IsWhollyKnown() {
+		return def
+	}
+
+	return skeletonPlaceholder(ty)
+}
+
+// skeletonPlaceholder returns the zero value of the type, such as an empty string or list.
+func skeletonPlaceholder(ty cty.Type) cty.Value {
+	switch {
+	case ty == cty.String:
+		return cty.StringVal("")
+	case ty == cty.Number:
+		return cty.Zero
+	case ty == cty.Bool:
+		return cty.False
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType())
+	case ty.IsMapType():
+		return cty.MapValEmpty(ty.ElementType())
+	case ty.IsTupleType():
+		elems := []cty.Value{}
+		for _, elem := range ty.TupleElementTypes() {
+			elems = append(elems, skeletonPlaceholder(elem))
+		}
+
+		if len(elems) == 0 {
+			return cty.EmptyTupleVal
+		}
+
+		return cty.TupleVal(elems)
+	case ty.IsObjectType():
+		attrs := map[string]cty.Value{}
+		for name, attr := range ty.AttributeTypes() {
+			attrs[name] = skeletonPlaceholder(attr)
+		}
+
+		if len(attrs) == 0 {
+			return cty.EmptyObjectVal
+		}
+
+		return cty.ObjectVal(attrs)
+	default:
+		// cty.DynamicPseudoType accepts any value
+		return cty.NullVal(cty.String)
+	}
+}
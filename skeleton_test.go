@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkeleton(tt *testing.T) {
+	tt.Run("Skeleton() writes required content and comments out optional content in HCL", func(t *testing.T) {
+		src, err := spec.NewSubset(&settingsSchema{}, &radioSchema{}).Skeleton(spec.SyntaxHCL)
+		assert.NoError(t, err)
+
+		assert.Equal(t, `timezone = ""
+
+# labels {
+# }
+
+settings {
+  # enabled = true
+  # limits = {}
+  # tags = []
+}
+
+# Radios assigned to stations.
+# radio "id" {
+#   # The frequency in MHz.
+#   frequency = 0
+# }
+`, string(src))
+	})
+
+	tt.Run("Skeleton() lists optional content within JSON comments", func(t *testing.T) {
+		src, err := spec.NewSubset(&settingsSchema{}, &radioSchema{}).Skeleton(spec.SyntaxJSON)
+		assert.NoError(t, err)
+
+		out := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(src, &out))
+
+		assert.Equal(t, map[string]interface{}{
+			"//":       "Optional: labels, radio.",
+			"timezone": "",
+			"settings": map[string]interface{}{"//": "Optional: enabled, limits, tags."},
+		}, out)
+	})
+
+	tt.Run("Skeleton() output parses against the schema", func(t *testing.T) {
+		for _, syntax := range []spec.Syntax{spec.SyntaxHCL, spec.SyntaxJSON} {
+			s := spec.NewSubset(&settingsSchema{}, &radioSchema{})
+
+			src, err := s.Skeleton(syntax)
+			assert.NoError(t, err)
+
+			filename := map[spec.Syntax]string{spec.SyntaxHCL: "skeleton.hcl", spec.SyntaxJSON: "skeleton.json"}[syntax]
+			assert.False(t, s.ParseSource(src, filename).HasErrors())
+			assert.False(t, s.Parse(&hcl.EvalContext{}).HasErrors(), filename)
+		}
+	})
+
+	tt.Run("Skeleton() rejects unknown syntaxes", func(t *testing.T) {
+		src, err := spec.NewSubset(&settingsSchema{}).Skeleton(spec.SyntaxUnknown)
+
+		assert.Nil(t, src)
+		assert.Error(t, err)
+	})
+}
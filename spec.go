@@ -23,8 +23,16 @@ type specFiles map[string]*hcl.File
 // parse raw bytes, files, and more against the schema. The Spec returns a custom Diagnostics
 // rather than the hcl.Diagnostics allowing easy manipulation of our own errors.
 type Spec struct {
-	registrar *parser.Registrar
-	files     specFiles
+	registrar    *parser.Registrar
+	files        specFiles
+	suppressions map[string][]*Suppression
+	policy       *Policy
+	sensitive    [][]string
+	ctx          *hcl.EvalContext
+	definitions  []*Definition
+	migrations   []*Migration
+	migrated     map[string]*hcl.File
+	validations  bool
 }
 
 // New creates a new Spec instance with the pre-ordered slice of parser.NamedBlockDefiniion
@@ -38,8 +46,9 @@ func New(defs parser.NamedBlockDefinitions) *Spec {
 	}
 
 	return &Spec{
-		registrar: registrar,
-		files:     specFiles{},
+		registrar:    registrar,
+		files:        specFiles{},
+		suppressions: map[string][]*Suppression{},
 	}
 }
 
@@ -54,8 +63,9 @@ func NewSubset(defs ...parser.NamedBlockDefinition) *Spec {
 	}
 
 	return &Spec{
-		registrar: registrar,
-		files:     specFiles{},
+		registrar:    registrar,
+		files:        specFiles{},
+		suppressions: map[string][]*Suppression{},
 	}
 }
 
@@ -65,27 +75,57 @@ func (s *Spec) Registrations() []*parser.Registration {
 	return s.registrar.Registrations()
 }
 
+// UseStrict enables or disables strict parsing. When strict, Parse returns an error for every
+// block or attribute not described by a registered BlockDefinition rather than ignoring it.
+func (s *Spec) UseStrict(strict bool) {
+	s.registrar.Strict = strict
+}
+
 // Build builds an hcldec.Spec instance from all registered BlockDefinition's.
 func (s *Spec) Build() hcldec.Spec {
 	return s.registrar.Build()
 }
 
-// Body returns an hcl.Body that merges all processed files into a single body for further
-// processing.
+// Body returns an hcl.Body that merges all processed files, ordered by filename, into a single
+// body for further processing. When migrations are in use the files migrated by the last call to
+// Parse are used in place of the originals and the VersionAttribute of each file is left out.
+// When validation blocks are in use they are left out of the bodies of all blocks.
 func (s *Spec) Body() hcl.Body {
-	files := []*hcl.File{}
+	bodies := []hcl.Body{}
 
-	for _, file := range s.files {
-		files = append(files, file)
+	for _, filename := range s.filenames() {
+		body := s.migratedFile(filename).Body
+		if s.versioned() {
+			_, body, _ = body.PartialContent(versionSchema)
+		}
+
+		bodies = append(bodies, body)
 	}
 
-	return hcl.MergeFiles(files)
+	if s.validations {
+		return &validationBody{Body: hcl.MergeBodies(bodies)}
+	}
+
+	return hcl.MergeBodies(bodies)
 }
 
 // Parse parses the provided hcl.Body, given the hcl.EvalContext against the generated
 // hcldec.Spec and ordered according to the order that the BlockDefinition's were defined.
+//
+// Files declaring an older version are migrated in memory first when migrations are in use, see
+// UseMigrations, and the conditions of validation blocks are evaluated after decoding when they
+// are in use, see UseValidations. The injected variables are recorded and may be traced back to
+// their configuration using Definitions. The labels referred to by the parser.Reference's of
+// the registered BlockDefinition's are checked against the labels of their target blocks. A
+// warning is returned for each use of a block or attribute deprecated by the parser.Metadata of
+// its registration. Diagnostics silenced by a suppression comment are moved to Suppressed and a
+// warning is returned for each suppression comment that did not silence any diagnostic.
 func (s *Spec) Parse(ctx *hcl.EvalContext) *Diagnostics {
-	return newDiagnostics(s, s.registrar.Parse(s.Body(), ctx))
+	s.ctx = ctx
+	diags := s.diagnostics(s.migrate().Extend(s.registrar.Parse(s.Body(), ctx)).Extend(s.validate()).Extend(s.references()).Extend(s.deprecations()))
+	s.define()
+
+	return diags.Merge(s.diagnostics(s.unusedSuppressions(diags.Suppressed)))
 }
 
 // Decode extracts the configuration within the given body into the given value. This value must
@@ -102,5 +142,5 @@ func (s *Spec) Parse(ctx *hcl.EvalContext) *Diagnostics {
 // partially-populated but may still be accessed by a careful caller for static analysis and editor
 // integration use-cases.
 func (s *Spec) Decode(ctx *hcl.EvalContext, val interface{}) *Diagnostics {
-	return newDiagnostics(s, gohcl.DecodeBody(s.Body(), ctx, val))
+	return s.diagnostics(gohcl.DecodeBody(s.Body(), ctx, val))
 }
@@ -95,6 +95,19 @@ func TestParse(tt *testing.T) {
 
 		assert.IsType(t, &spec.Diagnostics{}, diags)
 	})
+
+	tt.Run("Parse() reports unsupported content when strict", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("unit {\n  name = \"medic-12\"\n}\n\nstation \"st12\" {}\n"), "strict.hcl")
+
+		assert.False(t, s.Parse(&hcl.EvalContext{}).HasErrors())
+
+		s.UseStrict(true)
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.Len(t, diags.Raw(), 1)
+		assert.Equal(t, spec.CodeUnsupportedBlockType, spec.CodeOf(diags.Raw()[0]))
+	})
 }
 
 type decodeStruct struct {
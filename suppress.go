@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// diagnostic messages
+const (
+	DiagUnusedSuppression       = "Unused suppression comment"
+	DiagUnusedSuppressionDetail = "The suppression comment for %s does not match any diagnostic on the line it applies to and can be removed."
+)
+
+// suppressionPattern matches comments in the form of "# spec:ignore SPEC012 reason" where
+// multiple codes may be separated by commas.
+var suppressionPattern = regexp.MustCompile(`^(?:#|//)\s*spec:ignore\s+([A-Za-z0-9_,]+)\s*(.*?)\s*$`)
+
+// Suppression represents an inline "spec:ignore" comment that silences diagnostics with the
+// given codes. A comment on its own line applies to the next line containing configuration
+// while a comment following configuration applies to its own line.
+type Suppression struct {
+	Codes  []Code
+	Reason string
+	Range  hcl.Range
+	Line   int
+}
+
+// SuppressedDiagnostic is a diagnostic that was silenced by a Suppression.
+type SuppressedDiagnostic struct {
+	Diagnostic  *hcl.Diagnostic
+	Suppression *Suppression
+}
+
+// Suppressions returns all of the suppression comments found in the parsed HCL files.
+func (s *Spec) Suppressions() []*Suppression {
+	res := []*Suppression{}
+	filenames := s.ParsedFiles()
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		res = append(res, s.suppressions[filename]...)
+	}
+
+	return res
+}
+
+// matches returns true when the suppression applies to the diagnostic.
+func (sup *Suppression) matches(diag *hcl.Diagnostic) bool {
+	if diag.Subject == nil || diag.Subject.Filename != sup.Range.Filename || diag.Subject.Start.Line != sup.Line {
+		return false
+	}
+
+	code := CodeOf(diag)
+	if code == "" {
+		return false
+	}
+
+	for _, c := range sup.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findSuppressions lexes the HCL source and returns all suppression comments within it.
+func findSuppressions(src []byte, filename string) []*Suppression {
+	tokens, _ := hclsyntax.LexConfig(src, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	res := []*Suppression{}
+
+	for i, token := range tokens {
+		if token.Type != hclsyntax.TokenComment {
+			continue
+		}
+
+		match := suppressionPattern.FindStringSubmatch(strings.TrimSpace(string(token.Bytes)))
+		if match == nil {
+			continue
+		}
+
+		// comments include their trailing newline which we don't want in the range
+		text := strings.TrimRight(string(token.Bytes), "\r\n")
+		rng := token.Range
+		rng.End = hcl.Pos{
+			Line:   rng.Start.Line,
+			Column: rng.Start.Column + utf8.RuneCountInString(text),
+			Byte:   rng.Start.Byte + len(text),
+		}
+
+		sup := &Suppression{
+			Reason: match[2],
+			Range:  rng,
+			Line:   suppressionLine(tokens, i),
+		}
+
+		for _, code := range strings.Split(match[1], ",") {
+			if code != "" {
+				sup.Codes = append(sup.Codes, Code(code))
+			}
+		}
+
+		res = append(res, sup)
+	}
+
+	return res
+}
+
+// suppressionLine returns the line the comment at index i applies to, or 0 when the comment
+// is not followed by any configuration.
+func suppressionLine(tokens hclsyntax.Tokens, i int) int {
+	comment := tokens[i]
+
+	if i > 0 {
+		prev := tokens[i-1]
+		if prev.Type != hclsyntax.TokenNewline && prev.Type != hclsyntax.TokenComment &&
+			prev.Range.Start.Line == comment.Range.Start.Line {
+			return comment.Range.Start.Line
+		}
+	}
+
+	for _, next := range tokens[i+1:] {
+		switch next.Type {
+		case hclsyntax.TokenComment, hclsyntax.TokenNewline:
+			continue
+		case hclsyntax.TokenEOF:
+			return 0
+		default:
+			return next.Range.Start.Line
+		}
+	}
+
+	return 0
+}
+
+// suppress separates the diagnostics silenced by a suppression comment from those that
+// remain.
+func (s *Spec) suppress(diags hcl.Diagnostics) (hcl.Diagnostics, []*SuppressedDiagnostic) {
+	res := hcl.Diagnostics{}
+	suppressed := []*SuppressedDiagnostic{}
+
+	for _, diag := range diags {
+		if sup := s.suppressionFor(diag); sup != nil {
+			suppressed = append(suppressed, &SuppressedDiagnostic{
+				Diagnostic:  diag,
+				Suppression: sup,
+			})
+
+			continue
+		}
+
+		res = append(res, diag)
+	}
+
+	return res, suppressed
+}
+
+func (s *Spec) suppressionFor(diag *hcl.Diagnostic) *Suppression {
+	if diag.Subject == nil {
+		return nil
+	}
+
+	for _, sup := range s.suppressions[diag.Subject.Filename] {
+		if sup.matches(diag) {
+			return sup
+		}
+	}
+
+	return nil
+}
+
+// unusedSuppressions returns a warning for each suppression comment that did not silence any
+// of the suppressed diagnostics.
+func (s *Spec) unusedSuppressions(suppressed []*SuppressedDiagnostic) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	used := map[*Suppression]bool{}
+	for _, sd := range suppressed {
+		used[sd.Suppression] = true
+	}
+
+	for _, sup := range s.Suppressions() {
+		if used[sup] {
+			continue
+		}
+
+		codes := make([]string, 0, len(sup.Codes))
+		for _, code := range sup.Codes {
+			codes = append(codes, string(code))
+		}
+
+		rng := sup.Range
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  DiagUnusedSuppression,
+			Detail:   fmt.Sprintf(DiagUnusedSuppressionDetail, strings.Join(codes, ", ")),
+			Subject:  &rng,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type unitSchema struct{}
+
+func (u *unitSchema) Name() string {
+	return "unit"
+}
+
+func (u *unitSchema) Spec() hcldec.Spec {
+	return &hcldec.BlockSpec{
+		TypeName: "unit",
+		Nested: &hcldec.AttrSpec{
+			Name:     "name",
+			Type:     cty.String,
+			Required: true,
+		},
+	}
+}
+
+func TestSuppressions(tt *testing.T) {
+	tt.Run("a comment above the line suppresses the diagnostic", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("# spec:ignore SPEC012 name is set by the importer\nunit {\n}\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.Equal(t, 0, diags.Len())
+		assert.Len(t, diags.Suppressed, 1)
+		assert.Equal(t, "name is set by the importer", diags.Suppressed[0].Suppression.Reason)
+	})
+
+	tt.Run("a trailing comment suppresses the diagnostic on its own line", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("unit {} # spec:ignore SPEC010,SPEC012\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.False(t, diags.HasErrors())
+		assert.Len(t, diags.Suppressed, 1)
+		assert.Equal(t, []spec.Code{"SPEC010", "SPEC012"}, s.Suppressions()[0].Codes)
+	})
+
+	tt.Run("unused suppressions are reported as warnings", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("unit {\n  # spec:ignore SPEC012\n  name = \"Medic 1\"\n}\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.False(t, diags.HasErrors())
+		assert.Equal(t, 1, diags.WithCode(spec.CodeUnusedSuppression).Len())
+		assert.Equal(t, 2, diags.Raw()[0].Subject.Start.Line)
+	})
+
+	tt.Run("other diagnostics are not suppressed", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("# spec:ignore SPEC011\nunit {\n}\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+
+		assert.True(t, diags.HasErrors())
+		assert.Len(t, diags.Suppressed, 0)
+	})
+
+	tt.Run("suppressions used by a previous Parse are reported when unused", func(t *testing.T) {
+		s := spec.NewSubset(&unitSchema{})
+		s.ParseHCL([]byte("unit {\n  # spec:ignore SPEC016\n  name = importer.name\n}\n"), "unit.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{})
+		assert.Equal(t, 0, diags.Len())
+		assert.Len(t, diags.Suppressed, 1)
+
+		diags = s.Parse(&hcl.EvalContext{
+			Variables: map[string]cty.Value{"importer": cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("Medic 1")})},
+		})
+		assert.Equal(t, 1, diags.WithCode(spec.CodeUnusedSuppression).Len())
+	})
+}
@@ -0,0 +1,13 @@
+station "st12" {
+  name    = "Station 12"
+  channel = 12
+
+  unit {
+    callsign = upper("medic-${station.st13.channel}")
+  }
+}
+
+station "st13" {
+  name    = "Station 13"
+  channel = 13
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ValidationBlock is the type of the blocks declaring a condition that must hold for the
+// enclosing block, and SelfVariable the variable containing the attributes of the enclosing
+// block within the condition and error message.
+const (
+	ValidationBlock = "validation"
+	SelfVariable    = "self"
+)
+
+// diagnostic messages
+const (
+	DiagValidationFailed          = "Validation failed"
+	DiagValidationFailedSensitive = "The error_message refers to sensitive values and is not shown."
+	DiagInvalidValidation         = "Invalid validation"
+	DiagInvalidValidationCond     = "The condition must be true or false but is %s."
+	DiagInvalidValidationMessage  = "The error_message must be a string."
+)
+
+var validationSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "condition", Required: true},
+		{Name: "error_message", Required: true},
+	},
+}
+
+// UseValidations enables or disables validation blocks. When enabled, every block may contain
+// any number of ValidationBlock's, such as:
+//
+//	station "st12" {
+//	  channel = 12
+//
+//	  validation {
+//	    condition     = self.channel <= 16
+//	    error_message = "Stations must use one of the 16 channels."
+//	  }
+//	}
+//
+// After decoding, Parse evaluates each condition using the hcl.EvalContext with SelfVariable
+// added, holding an object of the attributes of the enclosing block where those that are not set
+// are null. An error using the error_message is returned on the enclosing block when a condition
+// is false, unless the message refers to values marked parser.Sensitive. Validation blocks are
+// ignored by blocks whose BlockDefinition already describe a "validation" block or attribute.
+func (s *Spec) UseValidations(enabled bool) {
+	s.validations = enabled
+}
+
+// validate evaluates the validation blocks within the parsed files.
+func (s *Spec) validate() hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	if !s.validations {
+		return diags
+	}
+
+	spec := s.Build()
+
+	for _, filename := range s.filenames() {
+		diags = diags.Extend(s.bodyValidations(s.migratedFile(filename).Body, spec, nil))
+	}
+
+	return diags
+}
+
+// bodyValidations evaluates the validation blocks of the enclosing block, when there is one, and
+// of all nested blocks.
+func (s *Spec) bodyValidations(body hcl.Body, spec hcldec.Spec, enclosing *hcl.Block) hcl.Diagnostics {
+	diags := hcl.Diagnostics{}
+
+	schema := hcldec.ImpliedSchema(spec)
+	validated := false
+
+	if enclosing != nil {
+		validated = withValidationSchema(schema) != schema
+		schema = withValidationSchema(schema)
+	}
+
+	content, _, _ := body.PartialContent(schema)
+	if content == nil {
+		return diags
+	}
+
+	introspected := parser.Introspect(spec)
+
+	if validated {
+		self := map[string]cty.Value{}
+
+		// every attribute of the block is included, as null when it is not set, so conditions
+		// may test whether optional attributes were set
+		for name, sa := range introspected.Attributes {
+			self[name] = cty.NullVal(sa.Type)
+
+			attr, ok := content.Attributes[name]
+			if !ok {
+				continue
+			}
+
+			val, valDiags := attr.Expr.Value(s.ctx)
+			if valDiags.HasErrors() {
+				self[name] = cty.UnknownVal(sa.Type)
+				continue
+			}
+
+			unmarked, marks := parser.Unmark(val)
+
+			if val, err := convert.Convert(unmarked, sa.Type); err == nil {
+				self[name] = val.WithMarks(marks)
+			} else {
+				self[name] = cty.UnknownVal(sa.Type)
+			}
+		}
+
+		ctx := s.ctx.NewChild()
+		ctx.Variables = map[string]cty.Value{SelfVariable: cty.ObjectVal(self)}
+
+		for _, block := range content.Blocks {
+			if block.Type == ValidationBlock {
+				diags = diags.Extend(evaluateValidation(block, enclosing, ctx))
+			}
+		}
+	}
+
+	for _, block := range content.Blocks {
+		if sb := introspected.Blocks[block.Type]; sb != nil && sb.Body != nil {
+			diags = diags.Extend(s.bodyValidations(block.Body, sb.Body.Spec, block))
+		}
+	}
+
+	return diags
+}
+
+// evaluateValidation evaluates a single validation block of the enclosing block.
+func evaluateValidation(block, enclosing *hcl.Block, ctx *hcl.EvalContext) hcl.Diagnostics {
+	content, diags := block.Body.Content(validationSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	condAttr := content.Attributes["condition"]
+
+	cond, condDiags := condAttr.Expr.Value(ctx)
+	diags = diags.Extend(condDiags)
+
+	// marks such as parser.Sensitive are kept by the values computed from marked variables
+	cond, _ = parser.Unmark(cond)
+
+	if condDiags.HasErrors() || !cond.IsKnown() {
+		return diags
+	}
+
+	converted, err := convert.Convert(cond, cty.Bool)
+	if err != nil || converted.IsNull() {
+		got := cond.Type().FriendlyName()
+		if cond.IsNull() {
+			got = "null"
+		}
+
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  DiagInvalidValidation,
+			Detail:   fmt.Sprintf(DiagInvalidValidationCond, got),
+			Subject:  condAttr.Expr.Range().Ptr(),
+			Context:  condAttr.Range.Ptr(),
+		})
+	}
+
+	if converted.True() {
+		return diags
+	}
+
+	msgAttr := content.Attributes["error_message"]
+
+	msg, msgDiags := msgAttr.Expr.Value(ctx)
+	diags = diags.Extend(msgDiags)
+
+	if msgDiags.HasErrors() {
+		return diags
+	}
+
+	msg, marks := parser.Unmark(msg)
+
+	msg, err = convert.Convert(msg, cty.String)
+	if err != nil || msg.IsNull() || !msg.IsKnown() {
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  DiagInvalidValidation,
+			Detail:   DiagInvalidValidationMessage,
+			Subject:  msgAttr.Expr.Range().Ptr(),
+			Context:  msgAttr.Range.Ptr(),
+		})
+	}
+
+	detail := msg.AsString()
+	if _, ok := marks[parser.Sensitive]; ok {
+		detail = DiagValidationFailedSensitive
+	}
+
+	return diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  DiagValidationFailed,
+		Detail:   detail,
+		Subject:  enclosing.DefRange.Ptr(),
+		Context:  condAttr.Range.Ptr(),
+	})
+}
+
+// withValidationSchema returns a copy of the schema allowing validation blocks, or the schema
+// itself when it already describes a "validation" block or attribute.
+func withValidationSchema(schema *hcl.BodySchema) *hcl.BodySchema {
+	for _, attr := range schema.Attributes {
+		if attr.Name == ValidationBlock {
+			return schema
+		}
+	}
+
+	for _, block := range schema.Blocks {
+		if block.Type == ValidationBlock {
+			return schema
+		}
+	}
+
+	return &hcl.BodySchema{
+		Attributes: schema.Attributes,
+		Blocks:     append(schema.Blocks[:len(schema.Blocks):len(schema.Blocks)], hcl.BlockHeaderSchema{Type: ValidationBlock}),
+	}
+}
+
+// validationBody is a hcl.Body ignoring the validation blocks within the bodies of its blocks,
+// which are evaluated separately after decoding.
+type validationBody struct {
+	hcl.Body
+
+	nested bool
+}
+
+func (b *validationBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, diags := b.Body.Content(b.schema(schema))
+	return b.content(schema, content), diags
+}
+
+func (b *validationBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := b.Body.PartialContent(b.schema(schema))
+	return b.content(schema, content), &validationBody{Body: remain, nested: b.nested}, diags
+}
+
+func (b *validationBody) schema(schema *hcl.BodySchema) *hcl.BodySchema {
+	if !b.nested {
+		return schema
+	}
+
+	return withValidationSchema(schema)
+}
+
+// content removes the validation blocks not described by the schema and wraps the bodies of the
+// remaining blocks.
+func (b *validationBody) content(schema *hcl.BodySchema, content *hcl.BodyContent) *hcl.BodyContent {
+	if content == nil {
+		return nil
+	}
+
+	strip := b.nested && b.schema(schema) != schema
+	blocks := hcl.Blocks{}
+
+	for _, block := range content.Blocks {
+		if strip && block.Type == ValidationBlock {
+			continue
+		}
+
+		wrapped := *block
+		wrapped.Body = &validationBody{Body: block.Body, nested: true}
+		blocks = append(blocks, &wrapped)
+	}
+
+	res := *content
+	res.Blocks = blocks
+
+	return &res
+}
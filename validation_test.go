@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+const validatedStations = `station "st12" {
+  name    = "Station 12"
+  channel = 12
+
+  validation {
+    condition     = self.channel <= 16
+    error_message = "Station ${self.name} must use one of the 16 channels."
+  }
+
+  unit {
+    callsign = "medic-12"
+
+    validation {
+      condition     = strlen(self.callsign) > 3
+      error_message = "Callsigns must be longer than 3 characters."
+    }
+  }
+}
+
+station "st13" {
+  name    = "Station 13"
+  channel = 20
+
+  validation {
+    condition     = self.channel <= 16
+    error_message = "Station ${self.name} must use one of the 16 channels."
+  }
+
+  validation {
+    condition     = self.channel != station.st12.channel
+    error_message = "Stations must not share a channel."
+  }
+}
+`
+
+func parseValidated(src string, enabled bool) (*spec.Spec, *spec.Diagnostics) {
+	s := spec.NewSubset(&stationSchema{})
+	s.UseValidations(enabled)
+	s.ParseSource([]byte(src), "validated.hcl")
+
+	return s, s.Parse(&hcl.EvalContext{Functions: map[string]function.Function{"strlen": stdlib.StrlenFunc}})
+}
+
+func TestUseValidations(tt *testing.T) {
+	tt.Run("validation blocks are unsupported unless enabled", func(t *testing.T) {
+		_, diags := parseValidated(validatedStations, false)
+
+		assert.Contains(t, diagnosticCodes(diags), spec.CodeUnsupportedBlockType)
+	})
+
+	tt.Run("Parse() reports failed conditions on the enclosing block", func(t *testing.T) {
+		_, diags := parseValidated(validatedStations, true)
+
+		assert.Equal(t, []spec.Code{spec.CodeValidationFailed}, diagnosticCodes(diags))
+
+		raw := diags.Raw()[0]
+		assert.Equal(t, "Station Station 13 must use one of the 16 channels.", raw.Detail)
+		assert.Equal(t, 20, raw.Subject.Start.Line)
+		assert.Equal(t, 1, raw.Subject.Start.Column)
+	})
+
+	tt.Run("conditions may refer to variables and nested blocks are validated", func(t *testing.T) {
+		src := `station "st12" {
+  name    = "Station 12"
+  channel = 12
+
+  unit {
+    callsign = "m"
+
+    validation {
+      condition     = strlen(self.callsign) > 3
+      error_message = "Callsigns must be longer than 3 characters."
+    }
+  }
+}
+
+station "st13" {
+  name    = "Station 13"
+  channel = 12
+
+  validation {
+    condition     = self.channel != station.st12.channel
+    error_message = "Stations must not share a channel."
+  }
+}
+`
+		_, diags := parseValidated(src, true)
+
+		details := []string{}
+		for _, diag := range diags.Raw() {
+			assert.Equal(t, spec.DiagValidationFailed, diag.Summary)
+			details = append(details, diag.Detail)
+		}
+
+		assert.ElementsMatch(t, []string{
+			"Callsigns must be longer than 3 characters.",
+			"Stations must not share a channel.",
+		}, details)
+	})
+
+	tt.Run("attributes that are not set are null within conditions", func(t *testing.T) {
+		src := `station "st12" {
+  name = "Station 12"
+
+  validation {
+    condition     = self.channel == null ? true : self.channel <= 16
+    error_message = "Stations must use one of the 16 channels."
+  }
+}
+
+station "st13" {
+  name    = "Station 13"
+  channel = "20"
+
+  validation {
+    condition     = self.channel == null ? true : self.channel <= 16
+    error_message = "Stations must use one of the 16 channels."
+  }
+}
+`
+		_, diags := parseValidated(src, true)
+
+		assert.Equal(t, []spec.Code{spec.CodeValidationFailed}, diagnosticCodes(diags), diags.Error())
+		assert.Equal(t, 10, diags.Raw()[0].Subject.Start.Line)
+	})
+
+	tt.Run("conditions may refer to sensitive variables", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.UseValidations(true)
+		s.ParseSource([]byte(`station "st12" {
+  name = "Station 12"
+
+  validation {
+    condition     = secrets.key == "y"
+    error_message = "The key ${secrets.key} is not valid."
+  }
+}
+`), "validated.hcl")
+
+		diags := s.Parse(&hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				"secrets": cty.ObjectVal(map[string]cty.Value{
+					"key": cty.StringVal("x"),
+				}).Mark(parser.Sensitive),
+			},
+		})
+
+		assert.Equal(t, []spec.Code{spec.CodeValidationFailed}, diagnosticCodes(diags), diags.Error())
+		assert.Equal(t, spec.DiagValidationFailedSensitive, diags.Raw()[0].Detail)
+	})
+
+	tt.Run("Parse() reports conditions that are not bool", func(t *testing.T) {
+		_, diags := parseValidated(`station "st12" {
+  name = "Station 12"
+
+  validation {
+    condition     = self.name
+    error_message = "Invalid."
+  }
+}
+`, true)
+
+		assert.Equal(t, []spec.Code{spec.CodeInvalidValidation}, diagnosticCodes(diags))
+		assert.Equal(t, `The condition must be true or false but is string.`, diags.Raw()[0].Detail)
+	})
+
+	tt.Run("Parse() reports validation blocks missing attributes", func(t *testing.T) {
+		_, diags := parseValidated(`station "st12" {
+  name = "Station 12"
+
+  validation {
+    condition = true
+  }
+}
+`, true)
+
+		assert.Equal(t, []spec.Code{spec.CodeMissingRequiredArgument}, diagnosticCodes(diags))
+	})
+
+	tt.Run("validation blocks are left out when decoding", func(t *testing.T) {
+		s, diags := parseValidated(`station "st12" {
+  name = "Station 12"
+
+  validation {
+    condition     = true
+    error_message = "Never."
+  }
+}
+`, true)
+
+		assert.False(t, diags.HasErrors())
+
+		var out struct {
+			Stations []struct {
+				ID   string   `hcl:"id,label"`
+				Name string   `hcl:"name"`
+				Rest hcl.Body `hcl:",remain"`
+			} `hcl:"station,block"`
+		}
+
+		assert.False(t, s.Decode(&hcl.EvalContext{}, &out).HasErrors())
+		assert.Equal(t, "Station 12", out.Stations[0].Name)
+	})
+
+	tt.Run("JSONSchema() allows validation blocks within blocks", func(t *testing.T) {
+		s := spec.NewSubset(&stationSchema{})
+		s.UseValidations(true)
+
+		res := s.JSONSchema()
+
+		assert.NotContains(t, res.Properties, spec.ValidationBlock)
+
+		station := res.Properties["station"].AdditionalProperties.(*spec.JSONSchema)
+		assert.Contains(t, station.Properties, spec.ValidationBlock)
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/responserms/spec/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// maxVariableValueLength is the longest rendered value shown as text before it is truncated.
+const maxVariableValueLength = 80
+
+// DiagnosticVariable is the value of a variable referenced by the expression that caused a
+// diagnostic. The Value of a Sensitive variable is always cty.NilVal.
+type DiagnosticVariable struct {
+	Name      string
+	Type      cty.Type
+	Value     cty.Value
+	Sensitive bool
+}
+
+// MarkSensitive marks variables as sensitive so their values are redacted when rendering the
+// variables referenced by diagnostics. Each path is a dot-separated traversal prefix such as
+// "secrets" or "station.*.password" where "*" matches any single step. Values marked with
+// parser.Sensitive are always redacted.
+func (s *Spec) MarkSensitive(paths ...string) {
+	for _, path := range paths {
+		s.sensitive = append(s.sensitive, strings.Split(path, "."))
+	}
+}
+
+// Variables returns the variables referenced by the expression of the diagnostic along with
+// their values from the hcl.EvalContext active when the diagnostic was produced. Variables that
+// cannot be resolved are omitted.
+func (d *Diagnostics) Variables(diag *hcl.Diagnostic) []*DiagnosticVariable {
+	res := []*DiagnosticVariable{}
+
+	if diag.Expression == nil || diag.EvalContext == nil {
+		return res
+	}
+
+	seen := map[string]bool{}
+
+	for _, traversal := range diag.Expression.Variables() {
+		val, diags := traversal.TraverseAbs(diag.EvalContext)
+		if diags.HasErrors() {
+			continue
+		}
+
+		steps := traversalSteps(traversal)
+		name := strings.Join(steps, ".")
+
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		unmarked, marks := parser.Unmark(val)
+		v := &DiagnosticVariable{
+			Name:      name,
+			Type:      unmarked.Type(),
+			Value:     unmarked,
+			Sensitive: d.isSensitive(steps, unmarked),
+		}
+
+		if _, ok := marks[parser.Sensitive]; ok {
+			v.Sensitive = true
+		}
+
+		if v.Sensitive {
+			v.Value = cty.NilVal
+		}
+
+		res = append(res, v)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// isSensitive returns true when the traversal steps match a path marked sensitive, or when the
+// value they refer to contains one, such as "station.st12" for the path "station.*.password".
+func (d *Diagnostics) isSensitive(steps []string, val cty.Value) bool {
+	if d.Spec == nil {
+		return false
+	}
+
+	for _, path := range d.Spec.sensitive {
+		matched := true
+		for i, part := range path {
+			if i >= len(steps) {
+				break
+			}
+
+			if part != "*" && part != steps[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched && (len(path) <= len(steps) || containsPath(val, path[len(steps):])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsPath returns true when the value has an element at the path, where "*" matches any
+// attribute, key or index.
+func containsPath(val cty.Value, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+
+	if val.IsNull() || !val.IsKnown() {
+		return false
+	}
+
+	ty := val.Type()
+
+	switch {
+	case ty.IsObjectType():
+		for name := range ty.AttributeTypes() {
+			if (path[0] == "*" || path[0] == name) && containsPath(val.GetAttr(name), path[1:]) {
+				return true
+			}
+		}
+	case val.CanIterateElements():
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+
+			step := "*"
+			switch key.Type() {
+			case cty.String:
+				step = key.AsString()
+			case cty.Number:
+				step = key.AsBigFloat().Text('f', -1)
+			}
+
+			if (path[0] == "*" || path[0] == step) && containsPath(elem, path[1:]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// String returns the variable as a single line of text in the form of "name (type) = value".
+func (v *DiagnosticVariable) String() string {
+	return fmt.Sprintf("%s (%s) = %s", v.Name, v.Type.FriendlyName(), v.valueString())
+}
+
+func (v *DiagnosticVariable) valueString() string {
+	switch {
+	case v.Sensitive:
+		return "(sensitive)"
+	case !v.Value.IsWhollyKnown():
+		return "(not yet known)"
+	}
+
+	str := string(bytes.TrimSpace(hclwrite.TokensForValue(v.Value).Bytes()))
+	str = strings.Join(strings.Fields(str), " ")
+
+	if runes := []rune(str); len(runes) > maxVariableValueLength {
+		str = string(runes[:maxVariableValueLength]) + "..."
+	}
+
+	return str
+}
+
+// traversalSteps returns the name of each step in the traversal. Index steps with values that
+// aren't strings or numbers are represented by "*".
+func traversalSteps(traversal hcl.Traversal) []string {
+	steps := make([]string, 0, len(traversal))
+
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			steps = append(steps, s.Name)
+		case hcl.TraverseAttr:
+			steps = append(steps, s.Name)
+		case hcl.TraverseIndex:
+			switch {
+			case !s.Key.IsKnown() || s.Key.IsNull():
+				steps = append(steps, "*")
+			case s.Key.Type() == cty.String:
+				steps = append(steps, s.Key.AsString())
+			case s.Key.Type() == cty.Number:
+				steps = append(steps, s.Key.AsBigFloat().Text('f', -1))
+			default:
+				steps = append(steps, "*")
+			}
+		default:
+			steps = append(steps, "*")
+		}
+	}
+
+	return steps
+}
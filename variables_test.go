@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Contaim, LLC
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/responserms/spec"
+	"github.com/responserms/spec/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func variableDiagnostics(t *testing.T, s *spec.Spec) *spec.Diagnostics {
+	expr, parseDiags := hclsyntax.ParseExpression(
+		[]byte(`"${station.channel}/${secrets.token}/${station.password}/${api.key}"`),
+		"expr.hcl",
+		hcl.Pos{Line: 1, Column: 1},
+	)
+	assert.False(t, parseDiags.HasErrors())
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"station": cty.ObjectVal(map[string]cty.Value{
+				"channel":  cty.NumberIntVal(12),
+				"password": cty.StringVal("hunter2"),
+			}),
+			"secrets": cty.ObjectVal(map[string]cty.Value{
+				"token": cty.StringVal("s3cret"),
+			}),
+			"api": cty.ObjectVal(map[string]cty.Value{
+				"key": cty.StringVal("abc123").Mark(parser.Sensitive),
+			}),
+		},
+	}
+
+	return &spec.Diagnostics{
+		Spec: s,
+		Diags: hcl.Diagnostics{
+			{
+				Severity:    hcl.DiagError,
+				Summary:     "Invalid channel",
+				Detail:      "The channel is not valid.",
+				Expression:  expr,
+				EvalContext: ctx,
+			},
+		},
+	}
+}
+
+func TestVariables(tt *testing.T) {
+	s := spec.NewSubset()
+	s.MarkSensitive("secrets", "*.password")
+
+	diags := variableDiagnostics(tt, s)
+
+	tt.Run("Variables() lists referenced variables with redacted sensitive values", func(t *testing.T) {
+		vars := diags.Variables(diags.Raw()[0])
+
+		assert.Len(t, vars, 4)
+		assert.Equal(t, "api.key", vars[0].Name)
+		assert.True(t, vars[0].Sensitive)
+		assert.Equal(t, "secrets.token", vars[1].Name)
+		assert.True(t, vars[1].Sensitive)
+		assert.Equal(t, "station.channel", vars[2].Name)
+		assert.Equal(t, cty.Number, vars[2].Type)
+		assert.True(t, vars[2].Value.RawEquals(cty.NumberIntVal(12)))
+		assert.True(t, vars[3].Sensitive)
+	})
+
+	tt.Run("WriteText() includes variables without sensitive values", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteText(b, 0, false))
+
+		assert.Contains(t, b.String(), "station.channel (number) = 12")
+		assert.Contains(t, b.String(), "secrets.token (string) = (sensitive)")
+		assert.NotContains(t, b.String(), "s3cret")
+		assert.NotContains(t, b.String(), "hunter2")
+		assert.NotContains(t, b.String(), "abc123")
+	})
+
+	tt.Run("WriteJSON() includes variables without sensitive values", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteJSON(b))
+
+		assert.Contains(t, b.String(), `"name": "station.channel"`)
+		assert.Contains(t, b.String(), `"type": "number"`)
+		assert.NotContains(t, b.String(), "s3cret")
+		assert.NotContains(t, b.String(), "hunter2")
+		assert.NotContains(t, b.String(), "abc123")
+	})
+
+	tt.Run("Variables() redacts values containing a sensitive path", func(t *testing.T) {
+		s := spec.NewSubset()
+		s.MarkSensitive("station.*.password")
+
+		expr, parseDiags := hclsyntax.ParseExpression([]byte(`"${station.st12}/${units}"`), "expr.hcl", hcl.Pos{Line: 1, Column: 1})
+		assert.False(t, parseDiags.HasErrors())
+
+		diags := &spec.Diagnostics{
+			Spec: s,
+			Diags: hcl.Diagnostics{
+				{
+					Severity:   hcl.DiagError,
+					Summary:    "Invalid station",
+					Expression: expr,
+					EvalContext: &hcl.EvalContext{
+						Variables: map[string]cty.Value{
+							"station": cty.ObjectVal(map[string]cty.Value{
+								"st12": cty.ObjectVal(map[string]cty.Value{"password": cty.StringVal("hunter2")}),
+							}),
+							"units": cty.NumberIntVal(4),
+						},
+					},
+				},
+			},
+		}
+
+		vars := diags.Variables(diags.Raw()[0])
+
+		assert.Len(t, vars, 2)
+		assert.Equal(t, "station.st12", vars[0].Name)
+		assert.True(t, vars[0].Sensitive)
+		assert.False(t, vars[1].Sensitive)
+
+		b := new(bytes.Buffer)
+		assert.NoError(t, diags.WriteText(b, 0, false))
+		assert.NotContains(t, b.String(), "hunter2")
+	})
+
+	tt.Run("Variables() redacts collections containing sensitive values", func(t *testing.T) {
+		expr, parseDiags := hclsyntax.ParseExpression([]byte(`length(api.keys)`), "expr.hcl", hcl.Pos{Line: 1, Column: 1})
+		assert.False(t, parseDiags.HasErrors())
+
+		diags := &spec.Diagnostics{
+			Spec: spec.NewSubset(),
+			Diags: hcl.Diagnostics{
+				{
+					Severity:   hcl.DiagError,
+					Summary:    "Invalid keys",
+					Expression: expr,
+					EvalContext: &hcl.EvalContext{
+						Variables: map[string]cty.Value{
+							"api": cty.ObjectVal(map[string]cty.Value{
+								"keys": cty.ListVal([]cty.Value{cty.StringVal("abc123").Mark(parser.Sensitive)}),
+							}).Mark(parser.Sensitive),
+						},
+					},
+				},
+			},
+		}
+
+		vars := diags.Variables(diags.Raw()[0])
+
+		assert.Len(t, vars, 1)
+		assert.True(t, vars[0].Sensitive)
+		assert.Equal(t, cty.List(cty.String), vars[0].Type)
+	})
+
+	tt.Run("String() truncates long values by rune", func(t *testing.T) {
+		v := &spec.DiagnosticVariable{Name: "name", Type: cty.String, Value: cty.StringVal(strings.Repeat("é", 100))}
+
+		assert.True(t, utf8.ValidString(v.String()))
+		assert.True(t, strings.HasSuffix(v.String(), "..."))
+	})
+}